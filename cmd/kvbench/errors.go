@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/savsgio/kvbench/internal/store"
+)
+
+// errorTypes are the names of the store errors counted by type.
+var errorTypes = []struct {
+	err  error
+	name string
+}{
+	{store.ErrEmptyKey, "empty_key"},
+	{store.ErrUnsupported, "unsupported"},
+}
+
+// errorType returns the type an error of an operation is counted by,
+// which is the Go type of the error it wraps unless it's a known store
+// error, so the types are bounded whatever the messages are.
+func errorType(err error) string {
+	for _, t := range errorTypes {
+		if errors.Is(err, t.err) {
+			return t.name
+		}
+	}
+
+	for {
+		next := errors.Unwrap(err)
+		if next == nil {
+			return fmt.Sprintf("%T", err)
+		}
+
+		err = next
+	}
+}
+
+// errorBudget is the number of failed operations a run tolerates,
+// after which it's aborted.
+type errorBudget struct {
+	max    uint64
+	n      uint64
+	done   chan struct{}
+	closed sync.Once
+}
+
+func newErrorBudget(max uint64) *errorBudget {
+	return &errorBudget{
+		max:  max,
+		done: make(chan struct{}),
+	}
+}
+
+// fail counts a failed operation, and reports whether the budget is
+// exceeded.
+func (b *errorBudget) fail() bool {
+	if atomic.AddUint64(&b.n, 1) <= b.max {
+		return false
+	}
+
+	b.closed.Do(func() {
+		close(b.done)
+	})
+
+	return true
+}
+
+// Done returns a channel that's closed when the budget is exceeded.
+func (b *errorBudget) Done() <-chan struct{} {
+	return b.done
+}
+
+// Err returns a non-nil error if the budget is exceeded.
+func (b *errorBudget) Err() error {
+	if n := atomic.LoadUint64(&b.n); n > b.max {
+		return fmt.Errorf("error budget exceeded: %d failed operations (max %d)", n, b.max)
+	}
+
+	return nil
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/savsgio/kvbench/internal/store"
+)
+
+func TestErrorType(t *testing.T) {
+	for _, tt := range []struct {
+		err  error
+		want string
+	}{
+		{fmt.Errorf("key %d: %w", 1, store.ErrEmptyKey), "empty_key"},
+		{store.ErrUnsupported, "unsupported"},
+		{fmt.Errorf("key %d: %w", 1, errTest), "*errors.errorString"},
+		{fmt.Errorf("key %d: %w", 2, &os.PathError{Op: "write", Path: "x", Err: syscall.ENOSPC}), "syscall.Errno"},
+	} {
+		if typ := errorType(tt.err); typ != tt.want {
+			t.Errorf("errorType(%v) == %q, want %q", tt.err, typ, tt.want)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// stringsFlag is a comma separated list of strings.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	*f = (*f)[:0]
+
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*f = append(*f, v)
+		}
+	}
+
+	return nil
+}
+
+// boolsFlag is a comma separated list of booleans.
+//
+// It behaves as a boolean flag, so it can be set without value.
+type boolsFlag []bool
+
+func (f *boolsFlag) String() string {
+	vs := make([]string, len(*f))
+
+	for i, v := range *f {
+		vs[i] = strconv.FormatBool(v)
+	}
+
+	return strings.Join(vs, ",")
+}
+
+func (f *boolsFlag) Set(value string) error {
+	*f = (*f)[:0]
+
+	for _, v := range strings.Split(value, ",") {
+		b, err := strconv.ParseBool(strings.TrimSpace(v))
+		if err != nil {
+			return err
+		}
+
+		*f = append(*f, b)
+	}
+
+	return nil
+}
+
+func (f *boolsFlag) IsBoolFlag() bool {
+	return true
+}
+
+// floatsFlag is a comma separated list of floats.
+type floatsFlag []float64
+
+func (f *floatsFlag) String() string {
+	vs := make([]string, len(*f))
+
+	for i, v := range *f {
+		vs[i] = strconv.FormatFloat(v, 'g', -1, 64)
+	}
+
+	return strings.Join(vs, ",")
+}
+
+func (f *floatsFlag) Set(value string) error {
+	*f = (*f)[:0]
+
+	for _, v := range strings.Split(value, ",") {
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return err
+		}
+
+		*f = append(*f, n)
+	}
+
+	return nil
+}
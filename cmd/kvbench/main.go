@@ -1,421 +1,415 @@
 package main
 
 import (
-	"context"
-	"encoding/binary"
 	"flag"
 	"fmt"
-	"math/rand"
+	"io"
 	"os"
 	"runtime"
 	"strings"
-	"sync"
-	"sync/atomic"
 	"time"
 
-	"github.com/savsgio/kvbench/internal/common"
-	"github.com/savsgio/kvbench/internal/providers/badger"
-	"github.com/savsgio/kvbench/internal/providers/buntdb"
-	"github.com/savsgio/kvbench/internal/providers/leveldb"
-	"github.com/savsgio/kvbench/internal/providers/nutsdb"
-	"github.com/savsgio/kvbench/internal/providers/pebble"
-	"github.com/savsgio/kvbench/internal/providers/pogreb"
+	"github.com/savsgio/kvbench/internal/generator"
+	_ "github.com/savsgio/kvbench/internal/providers"
+	"github.com/savsgio/kvbench/internal/result"
 	"github.com/savsgio/kvbench/internal/store"
+	"github.com/savsgio/kvbench/internal/workload"
 )
 
+const memorySuffix = "/memory"
+
 var (
-	duration = flag.Duration("d", time.Minute, "test duration for each case")
+	duration  = flag.Duration("d", time.Minute, "test duration for each case")
+	ops       = flag.Uint64("ops", 0, "number of operations of each case, instead of running for the test duration")
+	warmup    = flag.Duration("warmup", 0, "warm-up duration before measuring each case")
+	opTimeout = flag.Duration(
+		"optimeout", 0,
+		"timeout of each operation, after which it's abandoned and counted as a timeout (0 disables it)",
+	)
+	maxErrors = flag.Uint64(
+		"maxerrors", 0,
+		"number of failed operations each run tolerates in total, whatever their error type, before it's aborted",
+	)
 	c        = flag.Int("c", runtime.NumCPU(), "concurrent goroutines")
-	size     = flag.Int("size", 256, "data size")
-	fsync    = flag.Bool("fsync", false, "fsync")
-	s        = flag.String("s", "map", "store type")
+	cooldown = flag.Duration("cooldown", 0, "pause between the runs of the matrix")
+	format   = flag.String("format", result.FormatText, "output format (text, json or csv)")
+	output   = flag.String("o", "", "output file (default stdout)")
+	interval = flag.Duration("interval", time.Second, "sampling interval of the timeline of each case (0 disables it)")
+	tlOutput = flag.String("timeline", "", "csv output file of the timelines")
+	records  = flag.Uint64("records", 100000, "number of records of the key space, loaded before measuring")
+	keySize  = flag.Int("keysize", generator.DefaultKeySize, "key size")
+	keyFmt   = flag.String("keyformat", generator.KeyBinary, "key format: binary, decimal, hashed or tenant")
+	compress = flag.Float64(
+		"compressibility", generator.DefaultCompressibility,
+		"fraction of repeated bytes of the values (0 random, 1 all repeated)",
+	)
+	tenants = flag.Int("tenants", 16, "number of tenants of the tenant key format")
+	keyDist = flag.String(
+		"keydist", "",
+		"key distribution: uniform, zipfian, latest, sequential or hotspot "+
+			"(default sequential, or the one of the YCSB preset)",
+	)
+	skew = flag.Float64(
+		"skew", generator.DefaultSkew,
+		"skew of the key distribution: the zipfian constant for zipfian and latest, "+
+			"or the fraction of operations to the hot set for hotspot",
+	)
+	scanLength = flag.Int(
+		"scanlength", workload.DefaultMaxScanLength,
+		"maximum number of records of each scan, uniformly chosen in [1, scanlength], of the scan phase and the workloads that don't set their own",
+	)
+	wl = flag.String(
+		"workload", "default",
+		"workload to run: default (batch, set, sync, get, getview, setmixed, getmixed, scan, fullscan and del phases), "+
+			"a YCSB preset (a-f) or a mix of proportions (e.g. read=0.9,update=0.1,scan=0,insert=0,rmw=0)",
+	)
 
-	data = make([]byte, *size)
+	sizes  = stringsFlag{"256"}
+	fsyncs = boolsFlag{false}
+	stores = stringsFlag{"map"}
+	rates  = floatsFlag{0}
 )
 
-func main() {
-	flag.Parse()
-
-	fmt.Printf("duration=%v, c=%d size=%d\n", *duration, *c, *size)
+func init() {
+	flag.Var(
+		&sizes, "size",
+		"comma separated value sizes: N bytes, uniform:MIN-MAX, normal:MEAN:STDDEV "+
+			"or hist:FILE (with \"SIZE WEIGHT\" lines)",
+	)
+	flag.Var(&fsyncs, "fsync", "fsync (comma separated values to run both, e.g. -fsync=false,true)")
+	flag.Var(
+		&rates, "rate",
+		"comma separated target rates (op/s) of each phase, run in open loop "+
+			"with the latency measured from the scheduled start of each operation (0 runs in closed loop)",
+	)
+	flag.Var(
+		&stores, "s",
+		"comma separated store types, with optional \"/memory\" suffix "+
+			"(\"all\" selects every store, \"list\" prints the available ones)",
+	)
+}
 
-	var memory bool
-	var path string
+// run is a combination of the benchmark matrix.
+type run struct {
+	provider   store.Provider
+	memory     bool
+	fsync      bool
+	valueSizer generator.ValueSizer
+	rate       float64
+}
 
-	if strings.HasSuffix(*s, "/memory") {
-		memory = true
-		path = ":memory:"
-		*s = strings.TrimSuffix(*s, "/memory")
+func (r run) mode() string {
+	mode := "nofsync"
+	if r.fsync {
+		mode = "fsync"
 	}
 
-	st, path, err := getStore(*s, *fsync, path)
-	if err != nil {
-		panic(err)
+	if r.memory {
+		mode = "memory/" + mode
 	}
 
-	if !memory {
-		defer os.RemoveAll(path)
-	}
+	return mode
+}
 
-	defer st.Close()
+func (r run) String() string {
+	return fmt.Sprintf("%s/%s size=%s rate=%v", r.provider.Name, r.mode(), r.valueSizer, r.rate)
+}
 
-	name := *s
-	if memory {
-		name = name + "/memory"
-	}
+func main() {
+	flag.Parse()
 
-	if *fsync {
-		name = name + "/fsync"
-	} else {
-		name = name + "/nofsync"
+	if len(stores) == 1 && stores[0] == "list" {
+		listStores()
+
+		return
 	}
 
-	testBatchWrite(name, st)
-	testSet(name, st)
-	testGet(name, st)
-	testGetSet(name, st)
-	testDelete(name, st)
-}
+	runs, err := getRuns()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "kvbench: %v\n", err)
+		os.Exit(2)
+	}
 
-func genKey(i uint64) []byte {
-	r := make([]byte, 9)
-	r[0] = 'k'
-	binary.BigEndian.PutUint64(r[1:], i)
+	if *scanLength < 1 {
+		fmt.Fprintln(os.Stderr, "kvbench: the scan length must be greater than 0")
+		os.Exit(2)
+	}
 
-	return r
-}
+	var w *workload.Workload
 
-// test batch writes
-func testBatchWrite(name string, s store.DB) {
-	var wg sync.WaitGroup
+	if *wl != "default" {
+		parsed, err := workload.Parse(*wl, *scanLength)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "kvbench: %v\n", err)
+			os.Exit(2)
+		}
 
-	ctx, cancel := context.WithTimeout(context.Background(), *duration)
-	defer cancel()
+		w = &parsed
+	}
 
-	total := uint64(0)
-	start := time.Now()
+	keys, err := generator.NewKeyEncoder(*keyFmt, *keySize, *tenants)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "kvbench: %v\n", err)
+		os.Exit(2)
+	}
 
-	for i := 0; i < *c; i++ {
-		wg.Add(1)
+	if *records == 0 {
+		fmt.Fprintln(os.Stderr, "kvbench: the number of records must be greater than 0")
+		os.Exit(2)
+	}
 
-		go func(proc int) {
-			defer wg.Done()
+	dist := keyDistribution(w)
 
-			batchSize := uint64(1000)
-			var kvs []common.KV
+	if _, err := generator.NewChooser(dist, *records, *skew); err != nil {
+		fmt.Fprintf(os.Stderr, "kvbench: %v\n", err)
+		os.Exit(2)
+	}
 
-			for i := uint64(0); i < batchSize; i++ {
-				kvs = append(kvs, common.KV{
-					Key:   genKey(i),
-					Value: make([]byte, *size),
-				})
-			}
+	var out io.Writer = os.Stdout
 
-			for {
-				select {
-				case <-ctx.Done():
-					return
-				default:
-					// Fill random keys and values.
-					for i := range kvs {
-						kv := kvs[i]
-
-						rand.Read(kv.Key)
-						rand.Read(kv.Value)
-					}
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			panic(err)
+		}
 
-					err := s.SetBulk(kvs...)
-					if err != nil {
-						panic(err)
-					}
+		defer f.Close()
 
-					atomic.AddUint64(&total, uint64(len(kvs)))
-				}
-			}
-		}(i)
+		out = f
 	}
 
-	wg.Wait()
+	rw, err := result.NewWriter(*format, out)
+	if err != nil {
+		panic(err)
+	}
 
-	fmt.Printf(
-		"%s batch write test inserted: %d entries; took: %s s\n",
-		name, total, time.Since(start),
-	)
-}
+	if *tlOutput != "" {
+		f, err := os.Create(*tlOutput)
+		if err != nil {
+			panic(err)
+		}
 
-// test get
-func testGet(name string, s store.DB) {
-	var wg sync.WaitGroup
+		defer f.Close()
 
-	ctx, cancel := context.WithTimeout(context.Background(), *duration)
-	defer cancel()
+		rw = result.MultiWriter(rw, result.NewTimelineWriter(f))
+	}
 
-	counts := make([]int, *c)
-	start := time.Now()
+	if *format == result.FormatText {
+		limit := "duration=" + duration.String()
+		if *ops > 0 {
+			limit = fmt.Sprintf("ops=%d", *ops)
+		}
 
-	for j := 0; j < *c; j++ {
-		wg.Add(1)
+		fmt.Fprintf(out, "%s, c=%d size=%s\n", limit, *c, sizes.String())
+	}
 
-		index := uint64(j)
-		go func() {
-			defer wg.Done()
+	failed := false
 
-			i := index
+	for i, r := range runs {
+		if i > 0 && *cooldown > 0 {
+			time.Sleep(*cooldown)
+		}
 
-			for {
-				select {
-				case <-ctx.Done():
-					return
-				default:
-					v, _ := s.Get(genKey(i))
-					if len(v) == 0 {
-						i = index
-					}
+		if err := runBench(r, w, keys, dist, rw); err != nil {
+			fmt.Fprintf(os.Stderr, "kvbench: %s: %v\n", r, err)
 
-					i += uint64(*c)
-					counts[index]++
-				}
-			}
-		}()
+			failed = true
+		}
 	}
 
-	wg.Wait()
-
-	dur := time.Since(start)
-	d := int64(dur)
-
-	var n int
-	for _, count := range counts {
-		n += count
+	if err := rw.Flush(); err != nil {
+		panic(err)
 	}
 
-	fmt.Printf(
-		"%s get rate: %d op/s, mean: %d ns, took: %d s\n",
-		name, int64(n)*1e6/(d/1e3), d/int64((n)*(*c)), int(dur.Seconds()),
-	)
+	if failed {
+		os.Exit(1)
+	}
 }
 
-// test multiple get/one set
-func testGetSet(name string, s store.DB) {
-	var wg sync.WaitGroup
-
-	ch := make(chan struct{})
-	setCount := 0
+// getRuns returns the cartesian product of the selected stores,
+// fsync modes, value sizes and rates.
+func getRuns() ([]run, error) {
+	var runs []run
 
-	go func() {
-		i := uint64(0)
+	for _, rate := range rates {
+		if rate < 0 {
+			return nil, fmt.Errorf("invalid rate: %v", rate)
+		}
+	}
 
-		for {
-			select {
-			case <-ch:
-				return
-			default:
-				if err := s.Set(genKey(i), data); err != nil {
-					panic(err)
-				}
+	sizers := make([]generator.ValueSizer, len(sizes))
 
-				setCount++
-				i++
-			}
+	for i, spec := range sizes {
+		sizer, err := generator.ParseValueSizer(spec)
+		if err != nil {
+			return nil, err
 		}
-	}()
 
-	ctx, cancel := context.WithTimeout(context.Background(), *duration)
-	defer cancel()
+		sizers[i] = sizer
+	}
+
+	for _, name := range stores {
+		memory := strings.HasSuffix(name, memorySuffix)
+		name = strings.TrimSuffix(name, memorySuffix)
 
-	counts := make([]int, *c)
-	start := time.Now()
+		var providers []store.Provider
 
-	for j := 0; j < *c; j++ {
-		wg.Add(1)
+		if name == "all" {
+			for _, p := range store.Providers() {
+				if !memory || p.Memory {
+					providers = append(providers, p)
+				}
+			}
+		} else {
+			p, err := store.Lookup(name)
+			if err != nil {
+				return nil, err
+			}
 
-		index := uint64(j)
-		go func() {
-			defer wg.Done()
+			if memory && !p.Memory {
+				return nil, fmt.Errorf("%s: %w", name, store.ErrMemoryNotAllowed)
+			}
 
-			i := index
+			providers = append(providers, p)
+		}
 
-			for {
-				select {
-				case <-ctx.Done():
-					return
-				default:
-					v, _ := s.Get(genKey(i))
-					if len(v) == 0 {
-						i = index
+		for _, p := range providers {
+			for _, fsync := range fsyncs {
+				for _, sizer := range sizers {
+					for _, rate := range rates {
+						runs = append(runs, run{
+							provider:   p,
+							memory:     memory || p.Volatile,
+							fsync:      fsync,
+							valueSizer: sizer,
+							rate:       rate,
+						})
 					}
-
-					i += uint64(*c)
-					counts[index]++
 				}
 			}
-		}()
+		}
 	}
 
-	wg.Wait()
-
-	close(ch)
-
-	dur := time.Since(start)
-	d := int64(dur)
+	return runs, nil
+}
 
-	var n int
-	for _, count := range counts {
-		n += count
+// keyDistribution returns the key distribution of the workload w,
+// unless other one is set by the flags.
+func keyDistribution(w *workload.Workload) string {
+	switch {
+	case *keyDist != "":
+		return *keyDist
+	case w != nil:
+		return w.Distribution
+	default:
+		return generator.Sequential
 	}
+}
 
-	if setCount == 0 {
-		fmt.Printf("%s setmixed rate: -1 op/s, mean: -1 ns, took: %d s\n", name, int(dur.Seconds()))
+func runBench(r run, wl *workload.Workload, keys generator.KeyEncoder, dist string, w result.Writer) error {
+	path := ""
+	if r.memory {
+		path = store.MemoryPath
 	} else {
-		fmt.Printf(
-			"%s setmixed rate: %d op/s, mean: %d ns, took: %d s\n",
-			name, int64(setCount)*1e6/(d/1e3), d/int64(setCount), int(dur.Seconds()),
-		)
+		// Start from a clean directory, whatever a previous run left.
+		os.RemoveAll(r.provider.Path)
 	}
 
-	fmt.Printf(
-		"%s getmixed rate: %d op/s, mean: %d ns, took: %d s\n",
-		name, int64(n)*1e6/(d/1e3), d/int64((n)*(*c)), int(dur.Seconds()),
-	)
-}
-
-func testSet(name string, s store.DB) {
-	var wg sync.WaitGroup
+	seed := time.Now().UnixNano()
 
-	ctx, cancel := context.WithTimeout(context.Background(), *duration)
-	defer cancel()
-
-	counts := make([]int, *c)
-	start := time.Now()
-
-	for j := 0; j < *c; j++ {
-		wg.Add(1)
-
-		index := uint64(j)
-		go func() {
-			defer wg.Done()
-
-			i := index
-
-			for {
-				select {
-				case <-ctx.Done():
-					return
-				default:
-					if err := s.Set(genKey(i), data); err != nil {
-						panic(err)
-					}
+	values, err := generator.NewValueGenerator(r.valueSizer, *compress, seed)
+	if err != nil {
+		return err
+	}
 
-					i += uint64(*c)
-					counts[index]++
-				}
-			}
-		}()
+	st, path, err := r.provider.Open(path, r.fsync)
+	if err != nil {
+		return err
 	}
 
-	wg.Wait()
+	if !r.memory {
+		defer os.RemoveAll(path)
+	}
 
-	dur := time.Since(start)
-	d := int64(dur)
+	defer func() {
+		// Wait for the operations abandoned by the timeout, which still use
+		// the store.
+		store.Wait()
+		st.Close()
+	}()
 
-	var n int
-	for _, count := range counts {
-		n += count
+	b := &bench{
+		engine:  r.provider,
+		mode:    r.mode(),
+		keySize: *keySize,
+		values:  values,
+		keys:    keys,
+		keyDist: dist,
+		skew:    *skew,
+		records: *records,
+		rate:    r.rate,
+		seed:    seed,
+		db:      store.WithContext(st),
+		budget:  newErrorBudget(*maxErrors),
+		out:     w,
 	}
 
-	fmt.Printf(
-		"%s set rate: %d op/s, mean: %d ns, took: %d s\n",
-		name, int64(n)*1e6/(d/1e3), d/int64((n)*(*c)), int(dur.Seconds()),
-	)
-}
+	// The emulated scans would measure a full iteration each.
+	if wl != nil && wl.Scan > 0 && !r.provider.Supports(store.CapOrderedIter) {
+		return fmt.Errorf("%w: %s can't scan a range in order", store.ErrUnsupported, r.provider.Name)
+	}
 
-func testDelete(name string, s store.DB) {
-	var wg sync.WaitGroup
+	if err := b.load(); err != nil {
+		return fmt.Errorf("failed to load the records: %w", err)
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), *duration)
-	defer cancel()
+	if wl != nil {
+		b.testWorkload(*wl)
 
-	counts := make([]int, *c)
-	start := time.Now()
+		return b.budget.Err()
+	}
 
-	for j := 0; j < *c; j++ {
-		wg.Add(1)
+	phases := []func(){
+		b.testBatchWrite,
+		b.testSet,
+		b.testSync,
+		b.testGet,
+		b.testGetView,
+		b.testGetSet,
+	}
 
-		index := uint64(j)
-		go func() {
-			defer wg.Done()
+	if r.provider.Supports(store.CapOrderedIter) {
+		phases = append(phases, b.testScan)
+	}
 
-			i := index
+	if r.provider.Supports(store.CapIter) {
+		phases = append(phases, b.testFullScan)
+	}
 
-			for {
-				select {
-				case <-ctx.Done():
-					return
-				default:
-					if err := s.Del(genKey(i)); err != nil {
-						panic(err)
-					}
+	phases = append(phases, b.testDelete)
 
-					i += uint64(*c)
-					counts[index]++
-				}
+	// The phase exceeding the error budget is reported up to the abort.
+	for _, phase := range phases {
+		// The caches may still apply the writes of the previous phase,
+		// which the next one must not measure.
+		if !r.provider.Supports(store.CapRetention) {
+			if err := b.db.Sync(); err != nil {
+				return err
 			}
-		}()
-	}
-
-	wg.Wait()
+		}
 
-	dur := time.Since(start)
-	d := int64(dur)
+		phase()
 
-	var n int
-	for _, count := range counts {
-		n += count
+		if err := b.budget.Err(); err != nil {
+			return err
+		}
 	}
 
-	fmt.Printf(
-		"%s del rate: %d op/s, mean: %d ns, took: %d s\n",
-		name, int64(n)*1e6/(d/1e3), d/int64((n)*(*c)), int(dur.Seconds()),
-	)
+	return nil
 }
 
-func getStore(s string, fsync bool, path string) (store.DB, string, error) {
-	var st store.DB
-	var err error
-
-	switch s {
-	case "badger":
-		if path == "" {
-			path = "badger.db"
-		}
-		st, err = badger.New(path, fsync)
-	case "buntdb":
-		if path == "" {
-			path = "buntdb.db"
-		}
-		st, err = buntdb.New(path, fsync)
-	case "leveldb":
-		if path == "" {
-			path = "leveldb.db"
-		}
-		st, err = leveldb.New(path, fsync)
-	case "nutsdb":
-		if path == "" {
-			path = "nutsdb.db"
-		}
-		st, err = nutsdb.New(path, fsync)
-	case "pebble":
-		if path == "" {
-			path = "pebble.db"
-		}
-		st, err = pebble.New(path, fsync)
-	case "pogreb":
-		if path == "" {
-			path = "pogreb.db"
-		}
-		st, err = pogreb.New(path, fsync)
-	default:
-		err = fmt.Errorf("unknown store type: %v", s)
+func listStores() {
+	for _, p := range store.Providers() {
+		fmt.Println(p.Name)
 	}
-
-	return st, path, err
 }
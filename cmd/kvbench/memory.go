@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/savsgio/kvbench/internal/result"
+)
+
+// memStats are the allocation and GC measures of the process during
+// a phase, which include the ones of any concurrent phase.
+type memStats struct {
+	begin, end runtime.MemStats
+
+	// Peak heap in use sampled during the phase.
+	peakHeap uint64
+
+	// Peak heap in use sampled in each interval of the timeline.
+	heap map[int]uint64
+}
+
+// memSampler measures the memory of the process from the end of the
+// warm-up, sampling the heap in use every interval.
+type memSampler struct {
+	start    time.Time
+	interval time.Duration
+	stats    memStats
+	begun    sync.Once
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// startMemSampler starts measuring the memory of a phase whose measure
+// starts at start, sampling the heap in use at the intervals of the
+// timeline if they're set, or every second otherwise.
+func startMemSampler(start time.Time) *memSampler {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s := &memSampler{
+		start:    start,
+		interval: *interval,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+
+	if s.interval <= 0 {
+		s.interval = time.Second
+	}
+
+	if *interval > 0 {
+		s.stats.heap = make(map[int]uint64)
+	}
+
+	go s.run(ctx)
+
+	return s
+}
+
+func (s *memSampler) run(ctx context.Context) {
+	defer close(s.done)
+
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+
+	next := s.start
+
+	for {
+		next = next.Add(s.interval)
+		if !sleepUntil(ctx, timer, next) {
+			return
+		}
+
+		var ms runtime.MemStats
+		runtime.ReadMemStats(&ms)
+
+		s.sample(next, ms.HeapInuse)
+	}
+}
+
+// begin starts the measure, if it's not started yet. It's called by the
+// workers before each measured operation, since the sampler may not be
+// scheduled in time on a busy process.
+func (s *memSampler) begin() {
+	s.begun.Do(func() {
+		runtime.ReadMemStats(&s.stats.begin)
+	})
+}
+
+// sample records the heap in use at t, which is the scheduled time of
+// the periodic samples, so a late one is not taken for the next interval.
+func (s *memSampler) sample(t time.Time, heap uint64) {
+	if heap > s.stats.peakHeap {
+		s.stats.peakHeap = heap
+	}
+
+	if s.stats.heap == nil {
+		return
+	}
+
+	// The sample closes the interval that ends at t.
+	i := int((t.Sub(s.start) - 1) / s.interval)
+	if i < 0 {
+		i = 0
+	}
+
+	if heap > s.stats.heap[i] {
+		s.stats.heap[i] = heap
+	}
+}
+
+// stop ends the measure and returns it.
+func (s *memSampler) stop() *memStats {
+	s.cancel()
+	<-s.done
+
+	runtime.ReadMemStats(&s.stats.end)
+	s.sample(time.Now(), s.stats.end.HeapInuse)
+
+	// Nothing was measured if the phase ended during the warm-up.
+	s.begun.Do(func() {
+		s.stats.begin = s.stats.end
+	})
+
+	return &s.stats
+}
+
+// result returns the memory measures of a phase of the given number
+// of operations.
+func (m *memStats) result(ops uint64) result.Memory {
+	r := result.Memory{
+		AllocBytes: m.end.TotalAlloc - m.begin.TotalAlloc,
+		Allocs:     m.end.Mallocs - m.begin.Mallocs,
+		GCCycles:   m.end.NumGC - m.begin.NumGC,
+		GCPause:    time.Duration(m.end.PauseTotalNs - m.begin.PauseTotalNs),
+		PeakHeap:   m.peakHeap,
+	}
+
+	if ops > 0 {
+		r.AllocsPerOp = float64(r.Allocs) / float64(ops)
+		r.BytesPerOp = float64(r.AllocBytes) / float64(ops)
+	}
+
+	return r
+}
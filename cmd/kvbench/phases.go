@@ -0,0 +1,371 @@
+package main
+
+import (
+	"context"
+	"math/rand"
+	"strconv"
+	"sync"
+
+	"github.com/savsgio/kvbench/internal/common"
+	"github.com/savsgio/kvbench/internal/generator"
+	"github.com/savsgio/kvbench/internal/result"
+	"github.com/savsgio/kvbench/internal/store"
+	"github.com/savsgio/kvbench/internal/workload"
+)
+
+// Benchmark phases.
+const (
+	phaseBatchWrite = "batch"
+	phaseSet        = "set"
+	phaseSync       = "sync"
+	phaseGet        = "get"
+	phaseGetView    = "getview"
+	phaseSetMixed   = "setmixed"
+	phaseGetMixed   = "getmixed"
+	phaseScan       = "scan"
+	phaseFullScan   = "fullscan"
+	phaseDel        = "del"
+)
+
+type bench struct {
+	engine  store.Provider
+	mode    string
+	keySize int
+	values  *generator.ValueGenerator
+	keys    generator.KeyEncoder
+	keyDist string
+	skew    float64
+	records uint64
+	rate    float64
+	seed    int64
+	db      store.ContextDB
+	budget  *errorBudget
+	out     result.Writer
+}
+
+func (b *bench) report(phase string, res phaseResult) {
+	r := result.Result{
+		Engine:     b.engine.Name,
+		Mode:       b.mode,
+		Phase:      phase,
+		Records:    b.records,
+		KeySize:    b.keySize,
+		ValueSize:  b.values.Sizer().Mean(),
+		ValueDist:  valueDist(b.values.Sizer()),
+		Ops:        res.ops,
+		Duration:   res.took,
+		Throughput: res.rate(),
+		TargetRate: b.rate,
+		NotFound:   res.notFound,
+		Timeouts:   res.timeouts,
+		Errors:     res.errors,
+		Latency:    res.hist.Summary(),
+	}
+
+	if res.reads && res.ops > 0 {
+		hits := float64(res.ops-res.notFound) / float64(res.ops)
+		r.HitRatio = &hits
+
+		// Every read key was loaded, and none is deleted before the
+		// del phase, so the misses of a cache are its evictions.
+		if !b.engine.Supports(store.CapRetention) {
+			misses := 1 - hits
+			r.EvictionMissRatio = &misses
+			r.DroppedWrites = !b.engine.Supports(store.CapAdmitAll)
+		}
+	}
+
+	if res.mem != nil {
+		mem := res.mem.result(res.ops)
+		r.Memory = &mem
+	}
+
+	if res.timeline != nil {
+		r.Timeline = res.timeline.samples(res.took)
+
+		if res.mem != nil {
+			for i := range r.Timeline {
+				r.Timeline[i].HeapInuse = res.mem.heap[i]
+			}
+		}
+	}
+
+	if err := b.out.Write(r); err != nil {
+		panic(err)
+	}
+}
+
+// valueDist returns the spec of the value size distribution,
+// which is empty for fixed sizes.
+func valueDist(sizer generator.ValueSizer) string {
+	if spec := sizer.String(); spec != strconv.Itoa(sizer.Mean()) {
+		return spec
+	}
+
+	return ""
+}
+
+// newChooser returns a new key chooser of the bench distribution,
+// so every phase starts from the beginning of the sequential one.
+func (b *bench) newChooser() generator.Chooser {
+	ch, err := generator.NewChooser(b.keyDist, b.records, b.skew)
+	if err != nil {
+		panic(err)
+	}
+
+	return ch
+}
+
+// newRand returns the random source of the worker id.
+func (b *bench) newRand(id int) *rand.Rand {
+	return rand.New(rand.NewSource(b.seed + int64(id)))
+}
+
+// nextKey returns the key of the next record chosen by ch.
+func (b *bench) nextKey(ch generator.Chooser, r *rand.Rand) []byte {
+	return b.keys.Key(ch.Next(r, b.records))
+}
+
+// load populates the key space, out of any measure, syncing it so the
+// caches have applied every write.
+func (b *bench) load() error {
+	if err := workload.Load(b.db, b.records, b.keys.Key, b.values.Next, *c); err != nil {
+		return err
+	}
+
+	return b.db.Sync()
+}
+
+// test batch writes
+func (b *bench) testBatchWrite() {
+	batchSize := 1000
+	ch := b.newChooser()
+
+	// The number of operations counts the inserted entries.
+	batches := (*ops + uint64(batchSize) - 1) / uint64(batchSize)
+
+	res := b.runPhaseOps(*c, batches, b.rate/float64(batchSize), func(id int) opFunc {
+		r := b.newRand(id)
+		kvs := make([]common.KV, batchSize)
+
+		return func(ctx context.Context) error {
+			// Fill the chosen keys and generated values.
+			for i := range kvs {
+				kv := &kvs[i]
+
+				kv.Key = b.nextKey(ch, r)
+				kv.Value = b.values.Next(r)
+			}
+
+			err := b.db.SetBulkContext(ctx, kvs...)
+			if err != nil {
+				// An abandoned batch may still be reading the entries.
+				kvs = make([]common.KV, batchSize)
+			}
+
+			return err
+		}
+	})
+
+	// The throughput is measured in inserted entries,
+	// while the latency is measured per batch.
+	res.ops *= uint64(batchSize)
+
+	b.report(phaseBatchWrite, res)
+}
+
+// test get
+func (b *bench) testGet() {
+	res := b.runPhase(*c, b.getOp(b.newChooser()))
+	res.reads = true
+
+	b.report(phaseGet, res)
+}
+
+// test zero-copy gets, whose difference with the get phase is the cost
+// of copying the values
+func (b *bench) testGetView() {
+	ch := b.newChooser()
+
+	res := b.runPhase(*c, func(id int) opFunc {
+		r := b.newRand(id)
+
+		return func(ctx context.Context) error {
+			return b.db.ViewContext(ctx, b.nextKey(ch, r), discardValue)
+		}
+	})
+	res.reads = true
+
+	b.report(phaseGetView, res)
+}
+
+// test multiple get/one set
+func (b *bench) testGetSet() {
+	var wg sync.WaitGroup
+	var setRes phaseResult
+
+	// The writer runs as long as the readers.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		setOp := b.setOp(b.newChooser())
+
+		// Use a worker id after the ones of the readers, so the writer
+		// doesn't share their random source.
+		setRes = b.runPhaseUntil(ctx, 1, func(id int) opFunc {
+			return setOp(*c + id)
+		})
+	}()
+
+	getRes := b.runPhase(*c, b.getOp(b.newChooser()))
+	getRes.reads = true
+
+	cancel()
+	wg.Wait()
+
+	b.report(phaseSetMixed, setRes)
+	b.report(phaseGetMixed, getRes)
+}
+
+func (b *bench) testSet() {
+	res := b.runPhase(*c, b.setOp(b.newChooser()))
+
+	b.report(phaseSet, res)
+}
+
+// test short range scans
+func (b *bench) testScan() {
+	ch := b.newChooser()
+
+	res := b.runPhase(*c, func(id int) opFunc {
+		r := b.newRand(id)
+
+		return func(ctx context.Context) error {
+			rg := store.Range{Start: b.nextKey(ch, r), Limit: 1 + r.Intn(*scanLength)}
+
+			return b.db.IterRangeContext(ctx, rg, discard)
+		}
+	})
+
+	b.report(phaseScan, res)
+}
+
+// test full scans, in the order of the engine, since they don't need any
+func (b *bench) testFullScan() {
+	// The number of operations counts the scanned records.
+	scans := (*ops + b.records - 1) / b.records
+
+	res := b.runPhaseOps(*c, scans, b.rate/float64(b.records), func(id int) opFunc {
+		return func(ctx context.Context) error {
+			return b.db.IterContext(ctx, discard)
+		}
+	})
+
+	// The throughput is measured in scanned records,
+	// while the latency is measured per scan.
+	res.ops *= b.records
+
+	b.report(phaseFullScan, res)
+}
+
+// test writes followed by a sync barrier, whose cost is the difference
+// with the set phase
+func (b *bench) testSync() {
+	set := b.setOp(b.newChooser())
+
+	res := b.runPhase(*c, func(id int) opFunc {
+		op := set(id)
+
+		return func(ctx context.Context) error {
+			if err := op(ctx); err != nil {
+				return err
+			}
+
+			return b.db.SyncContext(ctx)
+		}
+	})
+
+	b.report(phaseSync, res)
+}
+
+func (b *bench) testDelete() {
+	ch := b.newChooser()
+
+	res := b.runPhase(*c, func(id int) opFunc {
+		r := b.newRand(id)
+
+		return func(ctx context.Context) error {
+			return b.db.DelContext(ctx, b.nextKey(ch, r))
+		}
+	})
+
+	b.report(phaseDel, res)
+}
+
+// test a YCSB-style workload
+func (b *bench) testWorkload(w workload.Workload) {
+	e := workload.NewExecutor(w, b.db, b.records, b.keys.Key, b.values.Next, b.newChooser())
+	ops := workload.Ops()
+
+	res := b.runMixedPhase(*c, len(ops), func(id int) mixedOpFunc {
+		wk := e.NewWorker(b.seed + int64(id))
+
+		return func(ctx context.Context) (int, error) {
+			op, err := wk.Next(ctx)
+
+			return int(op), err
+		}
+	})
+
+	b.report(w.Name, merge(res))
+
+	res[workload.OpRead].reads = true
+
+	for _, op := range ops {
+		// The memory is only measured for the whole workload.
+		res[op].mem = nil
+
+		if res[op].ops > 0 {
+			b.report(w.Name+"/"+op.String(), res[op])
+		}
+	}
+}
+
+// setOp writes the keys chosen by ch.
+func (b *bench) setOp(ch generator.Chooser) func(id int) opFunc {
+	return func(id int) opFunc {
+		r := b.newRand(id)
+
+		return func(ctx context.Context) error {
+			return b.db.SetContext(ctx, b.nextKey(ch, r), b.values.Next(r))
+		}
+	}
+}
+
+// getOp reads the keys chosen by ch.
+func (b *bench) getOp(ch generator.Chooser) func(id int) opFunc {
+	return func(id int) opFunc {
+		r := b.newRand(id)
+
+		return func(ctx context.Context) error {
+			_, err := b.db.GetContext(ctx, b.nextKey(ch, r))
+
+			return err
+		}
+	}
+}
+
+// discard ignores the records of a scan.
+func discard(key, value []byte) error {
+	return nil
+}
+
+// discardValue ignores the value of a view.
+func discardValue(value []byte) error {
+	return nil
+}
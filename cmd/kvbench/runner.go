@@ -0,0 +1,407 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/savsgio/kvbench/internal/histogram"
+	"github.com/savsgio/kvbench/internal/store"
+)
+
+// opFunc performs a single operation of a worker, which must return when
+// ctx is done.
+type opFunc func(ctx context.Context) error
+
+// mixedOpFunc performs a single operation of a worker and returns its kind,
+// as an index of the kinds the phase is run with.
+type mixedOpFunc func(ctx context.Context) (int, error)
+
+type phaseResult struct {
+	// Completed operations, including the ones of a missing key.
+	ops uint64
+
+	// Completed operations of a missing key.
+	notFound uint64
+
+	// Whether the operations are reads, whose hit ratio is reported.
+	reads bool
+
+	// Operations abandoned by the operation timeout, which are neither
+	// counted in ops nor recorded.
+	timeouts uint64
+
+	// Failed operations by error type, which are neither counted in ops
+	// nor recorded.
+	errors map[string]uint64
+
+	took     time.Duration
+	hist     *histogram.Histogram
+	timeline *timeline
+
+	// Memory measures of the whole phase, shared by all of its kinds.
+	mem *memStats
+}
+
+// rate returns the throughput in op/s.
+func (r phaseResult) rate() float64 {
+	if r.took <= 0 {
+		return 0
+	}
+
+	return float64(r.ops) / r.took.Seconds()
+}
+
+// fail counts a failed operation.
+func (r *phaseResult) fail(err error) {
+	if r.errors == nil {
+		r.errors = make(map[string]uint64)
+	}
+
+	r.errors[errorType(err)]++
+}
+
+// addCounters adds the counters of other to the ones of r,
+// other than the completed operations.
+func (r *phaseResult) addCounters(other phaseResult) {
+	r.notFound += other.notFound
+	r.timeouts += other.timeouts
+
+	for typ, n := range other.errors {
+		if r.errors == nil {
+			r.errors = make(map[string]uint64)
+		}
+
+		r.errors[typ] += n
+	}
+}
+
+// phaseContext returns the context of a phase, which expires after the
+// warm-up and test durations unless it runs a fixed number of operations.
+func phaseContext() (context.Context, context.CancelFunc) {
+	if *ops > 0 {
+		return context.WithCancel(context.Background())
+	}
+
+	return context.WithTimeout(context.Background(), *warmup+*duration)
+}
+
+func mixed(newOp func(id int) opFunc) func(id int) mixedOpFunc {
+	return func(id int) mixedOpFunc {
+		op := newOp(id)
+
+		return func(ctx context.Context) (int, error) {
+			return 0, op(ctx)
+		}
+	}
+}
+
+// runPhase runs the operations built by newOp in n concurrent workers
+// at the bench rate, until the warm-up and test durations expire or the
+// number of operations of the test is completed.
+func (b *bench) runPhase(n int, newOp func(id int) opFunc) phaseResult {
+	return b.runPhaseOps(n, *ops, b.rate, newOp)
+}
+
+// runPhaseOps is like runPhase, but completing the given number of
+// operations at the given rate, instead of the ones of the test.
+func (b *bench) runPhaseOps(n int, ops uint64, rate float64, newOp func(id int) opFunc) phaseResult {
+	ctx, cancel := phaseContext()
+	defer cancel()
+
+	return runWorkers(ctx, b.budget, n, 1, ops, rate, mixed(newOp))[0]
+}
+
+// runPhaseUntil runs the operations built by newOp in n concurrent workers
+// as fast as possible until ctx is done.
+func (b *bench) runPhaseUntil(ctx context.Context, n int, newOp func(id int) opFunc) phaseResult {
+	return runWorkers(ctx, b.budget, n, 1, 0, 0, mixed(newOp))[0]
+}
+
+// runMixedPhase is like runPhase, but returning the result of each one of
+// the given number of kinds of operations.
+func (b *bench) runMixedPhase(n, kinds int, newOp func(id int) mixedOpFunc) []phaseResult {
+	ctx, cancel := phaseContext()
+	defer cancel()
+
+	return runWorkers(ctx, b.budget, n, kinds, *ops, b.rate, newOp)
+}
+
+// runOp runs op, bounded by the operation timeout if it's set.
+//
+// Otherwise op runs with a context that can't be done, so the engines are
+// called directly rather than from a goroutine, and an operation in flight
+// when the phase ends is completed.
+func runOp(ctx context.Context, op mixedOpFunc) (int, error) {
+	if *opTimeout <= 0 {
+		return op(context.Background())
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, *opTimeout)
+	defer cancel()
+
+	return op(ctx)
+}
+
+// spinWait is the last stretch of a wait spent yielding the processor,
+// rather than waiting on a timer, which oversleeps by up to a millisecond:
+// in open loop, the oversleep would be measured as latency.
+const spinWait = 2 * time.Millisecond
+
+// sleepUntil waits until t or ctx is done, and reports whether t was
+// reached.
+func sleepUntil(ctx context.Context, timer *time.Timer, t time.Time) bool {
+	if d := time.Until(t) - spinWait; d > 0 {
+		timer.Reset(d)
+
+		select {
+		case <-ctx.Done():
+			if !timer.Stop() {
+				<-timer.C
+			}
+
+			return false
+		case <-timer.C:
+		}
+	}
+
+	for time.Now().Before(t) {
+		if ctx.Err() != nil {
+			return false
+		}
+
+		runtime.Gosched()
+	}
+
+	return true
+}
+
+// runWorkers runs the operations built by newOp in n concurrent workers
+// until ctx is done or, if ops is not zero, the workers complete ops
+// operations split among them. It returns the result of each one of the
+// given number of kinds of operations.
+//
+// If rate is not zero, the workers run in open loop: the operations are
+// scheduled at the given rate (op/s) regardless of how long the previous
+// ones took, and their latency is measured from their scheduled start time,
+// so the time waiting behind a slow operation is not omitted. Otherwise,
+// each worker runs the operations as fast as possible.
+//
+// The operations started during the warm-up are neither measured nor
+// counted, nor the ones abandoned when ctx is done. The ones exceeding
+// the operation timeout are only counted as timeouts, and the failed ones
+// by error type. Every failed operation is also charged to the budget,
+// which stops the workers once it's exceeded. The latency of every other
+// operation is recorded in a histogram per worker and kind, which are
+// merged when all of them have finished, and in a timeline per kind if
+// the sampling interval is set. The memory of the process is measured
+// along the phase too.
+//
+// The operations abandoned by the timeout are waited for before it
+// returns, out of the duration of the phase.
+func runWorkers(
+	ctx context.Context, budget *errorBudget, n, kinds int, ops uint64, rate float64, newOp func(id int) mixedOpFunc,
+) []phaseResult {
+	var wg sync.WaitGroup
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		select {
+		case <-budget.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	results := make([][]phaseResult, n)
+	now := time.Now()
+	start := now.Add(*warmup)
+
+	var timelines []*timeline
+
+	if *interval > 0 {
+		timelines = make([]*timeline, kinds)
+		for k := range timelines {
+			timelines[k] = newTimeline(start, *interval)
+		}
+	}
+
+	mem := startMemSampler(start)
+
+	// Time between the operations of each worker.
+	var interval time.Duration
+	if rate > 0 {
+		interval = time.Duration(float64(n) / rate * float64(time.Second))
+	}
+
+	for j := 0; j < n; j++ {
+		wg.Add(1)
+
+		rs := make([]phaseResult, kinds)
+		for k := range rs {
+			rs[k].hist = histogram.New()
+		}
+
+		results[j] = rs
+
+		// Operations of the worker, if the phase is limited by them.
+		quota := ops / uint64(n)
+		if uint64(j) < ops%uint64(n) {
+			quota++
+		}
+
+		// Spread the schedules of the workers along the interval.
+		next := now.Add(interval * time.Duration(j) / time.Duration(n))
+
+		var recorders []*timelineRecorder
+		for _, tl := range timelines {
+			recorders = append(recorders, tl.newRecorder())
+		}
+
+		op := newOp(j)
+
+		go func() {
+			defer wg.Done()
+
+			defer func() {
+				for _, r := range recorders {
+					r.flush()
+				}
+			}()
+
+			timer := time.NewTimer(time.Hour)
+			timer.Stop()
+
+			for ops == 0 || quota > 0 {
+				select {
+				case <-ctx.Done():
+					return
+				default:
+					t := time.Now()
+
+					if interval > 0 {
+						if !sleepUntil(ctx, timer, next) {
+							return
+						}
+
+						t = next
+						next = next.Add(interval)
+					}
+
+					if !t.Before(start) {
+						mem.begin()
+					}
+
+					kind, err := runOp(ctx, op)
+					r := &rs[kind]
+
+					switch {
+					case err == nil:
+					case errors.Is(err, store.ErrNotFound):
+						if !t.Before(start) {
+							r.notFound++
+						}
+					case ctx.Err() != nil:
+						// The phase ended during the operation.
+						return
+					case errors.Is(err, context.DeadlineExceeded):
+						if !t.Before(start) {
+							r.timeouts++
+							quota--
+						}
+
+						continue
+					default:
+						if !t.Before(start) {
+							r.fail(err)
+							quota--
+						}
+
+						// The other workers stop when ctx is canceled.
+						if budget.fail() {
+							return
+						}
+
+						continue
+					}
+
+					if !t.Before(start) {
+						end := time.Now()
+						d := end.Sub(t)
+
+						r.hist.Record(d)
+
+						if recorders != nil {
+							recorders[kind].record(end, d)
+						}
+
+						quota--
+					}
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	took := time.Since(start)
+	ms := mem.stop()
+
+	// The operations abandoned by the timeout still run in background,
+	// which must not spill into the next phase.
+	store.Wait()
+	res := make([]phaseResult, kinds)
+
+	for k := range res {
+		rs := make([]phaseResult, n)
+		for j := range results {
+			rs[j] = results[j][k]
+		}
+
+		r := &res[k]
+		*r = merge(rs)
+		r.took = took
+		r.mem = ms
+
+		if timelines != nil {
+			r.timeline = timelines[k]
+		}
+	}
+
+	return res
+}
+
+// merge returns the result of all the given ones, which must have been
+// run in the same phase.
+func merge(rs []phaseResult) phaseResult {
+	res := phaseResult{hist: histogram.New()}
+
+	for _, r := range rs {
+		res.hist.Merge(r.hist)
+		res.addCounters(r)
+
+		if res.mem == nil {
+			res.mem = r.mem
+		}
+
+		if r.timeline != nil {
+			if res.timeline == nil {
+				res.timeline = newTimeline(r.timeline.start, r.timeline.interval)
+			}
+
+			res.timeline.mergeTimeline(r.timeline)
+		}
+
+		if r.took > res.took {
+			res.took = r.took
+		}
+	}
+
+	res.ops = res.hist.Count()
+
+	return res
+}
@@ -0,0 +1,489 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/savsgio/kvbench/internal/generator"
+	"github.com/savsgio/kvbench/internal/result"
+	"github.com/savsgio/kvbench/internal/store"
+	"github.com/savsgio/kvbench/internal/workload"
+)
+
+// setFlag sets the flag of the given name until the end of the test.
+func setFlag(t *testing.T, name, value string) {
+	t.Helper()
+
+	old := flag.Lookup(name).Value.String()
+
+	if err := flag.Set(name, value); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		flag.Set(name, old)
+	})
+}
+
+// results collects the results written by a bench.
+type results []result.Result
+
+func (rs *results) Write(r result.Result) error {
+	*rs = append(*rs, r)
+
+	return nil
+}
+
+func (rs *results) Flush() error {
+	return nil
+}
+
+// phase returns the result of the phase.
+func (rs results) phase(t *testing.T, name string) result.Result {
+	t.Helper()
+
+	for _, r := range rs {
+		if r.Phase == name {
+			return r
+		}
+	}
+
+	t.Fatalf("no result of the %s phase", name)
+
+	return result.Result{}
+}
+
+// openMap returns a new store of the map provider.
+func openMap(t *testing.T) store.DB {
+	t.Helper()
+
+	p, err := store.Lookup("map")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, _, err := p.Open(store.MemoryPath, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+// newTestBench returns a bench of 100 records loaded in db, reported as
+// the ones of the map provider.
+func newTestBench(t *testing.T, db store.DB) (*bench, *results) {
+	t.Helper()
+
+	p, err := store.Lookup("map")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sizer, err := generator.ParseValueSizer("16")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := generator.NewValueGenerator(sizer, generator.DefaultCompressibility, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := generator.NewKeyEncoder(generator.KeyBinary, generator.DefaultKeySize, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := &results{}
+
+	b := &bench{
+		engine:  p,
+		mode:    "memory/nofsync",
+		keySize: generator.DefaultKeySize,
+		values:  values,
+		keys:    keys,
+		keyDist: generator.Sequential,
+		skew:    generator.DefaultSkew,
+		records: 100,
+		seed:    1,
+		db:      store.WithContext(db),
+		budget:  newErrorBudget(*maxErrors),
+		out:     out,
+	}
+
+	if err := b.load(); err != nil {
+		t.Fatalf("failed to load: %v", err)
+	}
+
+	return b, out
+}
+
+// testFlags sets the flags the runner reads to run without warm-up,
+// timeline nor timeout, unless the test sets them again.
+func testFlags(t *testing.T) {
+	t.Helper()
+
+	setFlag(t, "warmup", "0")
+	setFlag(t, "interval", "0")
+	setFlag(t, "optimeout", "0")
+	setFlag(t, "maxerrors", "0")
+	setFlag(t, "c", "1")
+}
+
+func TestRunWorkers_quota(t *testing.T) {
+	testFlags(t)
+
+	counts := make([]int, 3)
+
+	res := runWorkers(context.Background(), newErrorBudget(0), 3, 1, 1000, 0, func(id int) mixedOpFunc {
+		return func(ctx context.Context) (int, error) {
+			counts[id]++
+
+			return 0, nil
+		}
+	})
+
+	if res[0].ops != 1000 {
+		t.Errorf("ops == %d, want 1000", res[0].ops)
+	}
+
+	// The remainder goes to the first workers.
+	for id, want := range []int{334, 333, 333} {
+		if counts[id] != want {
+			t.Errorf("worker %d ran %d operations, want %d", id, counts[id], want)
+		}
+	}
+}
+
+func TestBench_ops(t *testing.T) {
+	testFlags(t)
+	setFlag(t, "ops", "500")
+	setFlag(t, "c", "2")
+
+	b, out := newTestBench(t, openMap(t))
+
+	b.testSet()
+	b.testGet()
+	b.testDelete()
+
+	for _, phase := range []string{phaseSet, phaseGet, phaseDel} {
+		if r := out.phase(t, phase); r.Ops != 500 {
+			t.Errorf("%s ops == %d, want 500", phase, r.Ops)
+		}
+	}
+
+	if r := out.phase(t, phaseGet); r.NotFound != 0 {
+		t.Errorf("get not found == %d, want 0", r.NotFound)
+	}
+}
+
+func TestRunWorkers_openLoop(t *testing.T) {
+	testFlags(t)
+
+	// 50 operations at 1000 op/s take at least 49ms, from the first one
+	// scheduled at the start of the phase to the last one.
+	res := runWorkers(context.Background(), newErrorBudget(0), 1, 1, 50, 1000, func(id int) mixedOpFunc {
+		return func(ctx context.Context) (int, error) {
+			return 0, nil
+		}
+	})
+
+	if res[0].ops != 50 {
+		t.Errorf("ops == %d, want 50", res[0].ops)
+	}
+
+	if took := res[0].took; took < 49*time.Millisecond {
+		t.Errorf("took %v, want at least 49ms", took)
+	}
+}
+
+func TestRunWorkers_coordinatedOmission(t *testing.T) {
+	testFlags(t)
+
+	// Operations of 5ms scheduled every 1ms queue behind each other, so the
+	// latency measured from their schedule grows up to ~20 * (5ms - 1ms).
+	res := runWorkers(context.Background(), newErrorBudget(0), 1, 1, 20, 1000, func(id int) mixedOpFunc {
+		return func(ctx context.Context) (int, error) {
+			time.Sleep(5 * time.Millisecond)
+
+			return 0, nil
+		}
+	})
+
+	if max := res[0].hist.Max(); max < 60*time.Millisecond {
+		t.Errorf("max latency == %v, want at least 60ms", max)
+	}
+}
+
+func TestRunWorkers_firstScheduled(t *testing.T) {
+	testFlags(t)
+
+	calls := 0
+
+	// The first operation is scheduled at the start of the phase, so it's
+	// measured rather than run out of the count.
+	res := runWorkers(context.Background(), newErrorBudget(0), 1, 1, 10, 1000, func(id int) mixedOpFunc {
+		return func(ctx context.Context) (int, error) {
+			calls++
+
+			return 0, nil
+		}
+	})
+
+	if res[0].ops != 10 || calls != 10 {
+		t.Errorf("%d operations measured of %d run, want 10 of 10", res[0].ops, calls)
+	}
+}
+
+func TestTimeline_buckets(t *testing.T) {
+	start := time.Now()
+	tl := newTimeline(start, time.Second)
+
+	r := tl.newRecorder()
+	for _, offset := range []time.Duration{
+		500 * time.Millisecond,
+		1500 * time.Millisecond,
+		1999 * time.Millisecond,
+		3200 * time.Millisecond,
+	} {
+		r.record(start.Add(offset), time.Millisecond)
+	}
+
+	r.flush()
+
+	// The empty intervals are sampled too, and the last one is partial.
+	samples := tl.samples(3500 * time.Millisecond)
+
+	want := []uint64{1, 2, 0, 1}
+	if len(samples) != len(want) {
+		t.Fatalf("got %d samples, want %d", len(samples), len(want))
+	}
+
+	for i, s := range samples {
+		if s.Ops != want[i] {
+			t.Errorf("sample %d has %d ops, want %d", i, s.Ops, want[i])
+		}
+
+		if off := time.Duration(i) * time.Second; s.Offset != off {
+			t.Errorf("sample %d offset == %v, want %v", i, s.Offset, off)
+		}
+	}
+
+	if tp := samples[3].Throughput; tp != 2 {
+		t.Errorf("throughput of the partial sample == %v, want 2", tp)
+	}
+}
+
+func TestRunWorkers_timeline(t *testing.T) {
+	testFlags(t)
+	setFlag(t, "interval", "20ms")
+
+	res := runWorkers(context.Background(), newErrorBudget(0), 2, 1, 50, 500, func(id int) mixedOpFunc {
+		return func(ctx context.Context) (int, error) {
+			return 0, nil
+		}
+	})[0]
+
+	samples := res.timeline.samples(res.took)
+
+	if n := int((res.took + 20*time.Millisecond - 1) / (20 * time.Millisecond)); len(samples) != n {
+		t.Errorf("got %d samples in %v, want %d", len(samples), res.took, n)
+	}
+
+	var ops uint64
+	for _, s := range samples {
+		ops += s.Ops
+	}
+
+	if ops != res.ops || ops != 50 {
+		t.Errorf("samples add up to %d ops, want 50", ops)
+	}
+}
+
+var errTest = errors.New("test error")
+
+// failingDB fails the reads of the store after ok of them.
+type failingDB struct {
+	store.DB
+	ok int64
+}
+
+func (db *failingDB) Get(key []byte) ([]byte, error) {
+	if atomic.AddInt64(&db.ok, -1) < 0 {
+		return nil, errTest
+	}
+
+	return db.DB.Get(key)
+}
+
+// slowDB delays the reads of the store, and counts the completed ones.
+type slowDB struct {
+	store.DB
+	delay time.Duration
+	reads int64
+}
+
+func (db *slowDB) Get(key []byte) ([]byte, error) {
+	time.Sleep(db.delay)
+	defer atomic.AddInt64(&db.reads, 1)
+
+	return db.DB.Get(key)
+}
+
+func TestBench_errorBudget(t *testing.T) {
+	testFlags(t)
+	setFlag(t, "ops", "100")
+	setFlag(t, "maxerrors", "5")
+
+	b, out := newTestBench(t, &failingDB{DB: openMap(t)})
+
+	b.testGet()
+
+	r := out.phase(t, phaseGet)
+
+	if n := r.ErrorCount(); n != 6 {
+		t.Errorf("%d failed operations, want the run aborted after 6", n)
+	}
+
+	if r.Ops != 0 {
+		t.Errorf("ops == %d, want 0", r.Ops)
+	}
+
+	if b.budget.Err() == nil {
+		t.Error("error budget not exceeded")
+	}
+}
+
+func TestBench_errorsWithinBudget(t *testing.T) {
+	testFlags(t)
+	setFlag(t, "ops", "100")
+	setFlag(t, "maxerrors", "5")
+
+	b, out := newTestBench(t, &failingDB{DB: openMap(t), ok: 95})
+
+	b.testGet()
+
+	r := out.phase(t, phaseGet)
+
+	// The failed operations don't count in the operations of the phase.
+	if n := r.ErrorCount(); n != 5 || r.Ops != 95 {
+		t.Errorf("%d ops and %d failed ones, want 95 and 5", r.Ops, n)
+	}
+
+	if err := b.budget.Err(); err != nil {
+		t.Errorf("error budget exceeded: %v", err)
+	}
+}
+
+func TestRunBench_abort(t *testing.T) {
+	testFlags(t)
+	setFlag(t, "ops", "100")
+	setFlag(t, "records", "100")
+	setFlag(t, "maxerrors", "5")
+
+	p := store.Provider{
+		Name:     "failing",
+		Memory:   true,
+		Volatile: true,
+		New: func(path string, fsync bool) (store.DB, error) {
+			return &failingDB{DB: openMap(t)}, nil
+		},
+	}
+
+	sizer, err := generator.ParseValueSizer("16")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := generator.NewKeyEncoder(generator.KeyBinary, generator.DefaultKeySize, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := &results{}
+
+	err = runBench(run{provider: p, memory: true, valueSizer: sizer}, nil, keys, generator.Sequential, out)
+	if err == nil || !strings.Contains(err.Error(), "error budget exceeded") {
+		t.Fatalf("run returned %v, want the error budget exceeded", err)
+	}
+
+	// The run is aborted after the phase exceeding the budget.
+	var phases []string
+	for _, r := range *out {
+		phases = append(phases, r.Phase)
+	}
+
+	want := []string{phaseBatchWrite, phaseSet, phaseSync, phaseGet}
+	if strings.Join(phases, ",") != strings.Join(want, ",") {
+		t.Errorf("reported phases %v, want %v", phases, want)
+	}
+}
+
+func TestBench_timeouts(t *testing.T) {
+	testFlags(t)
+	setFlag(t, "ops", "5")
+	setFlag(t, "optimeout", "2ms")
+
+	db := &slowDB{DB: openMap(t), delay: 20 * time.Millisecond}
+	b, out := newTestBench(t, db)
+
+	b.testGet()
+
+	// The abandoned reads are completed before the next phase.
+	if n := atomic.LoadInt64(&db.reads); n != 5 {
+		t.Errorf("%d abandoned reads completed after the phase, want 5", n)
+	}
+
+	r := out.phase(t, phaseGet)
+
+	if r.Timeouts != 5 || r.Ops != 0 {
+		t.Errorf("%d ops and %d timeouts, want 0 and 5", r.Ops, r.Timeouts)
+	}
+
+	if r.ErrorCount() != 0 {
+		t.Errorf("timeouts counted as errors: %v", r.Errors)
+	}
+}
+
+func TestRunBench_unorderedScan(t *testing.T) {
+	testFlags(t)
+
+	p, err := store.Lookup("map")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wl, err := workload.Parse("e", workload.DefaultMaxScanLength)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sizer, err := generator.ParseValueSizer("16")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := generator.NewKeyEncoder(generator.KeyBinary, generator.DefaultKeySize, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := &results{}
+
+	err = runBench(run{provider: p, memory: true, valueSizer: sizer}, &wl, keys, generator.Sequential, out)
+	if !errors.Is(err, store.ErrUnsupported) {
+		t.Fatalf("run returned %v, want %v", err, store.ErrUnsupported)
+	}
+
+	if len(*out) != 0 {
+		t.Errorf("reported %d results of a rejected workload", len(*out))
+	}
+}
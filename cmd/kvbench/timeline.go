@@ -0,0 +1,121 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/savsgio/kvbench/internal/histogram"
+	"github.com/savsgio/kvbench/internal/result"
+)
+
+// timeline aggregates the latencies of the operations of a phase by the
+// interval they completed in.
+type timeline struct {
+	mu       sync.Mutex
+	start    time.Time
+	interval time.Duration
+	hists    map[int]*histogram.Histogram
+}
+
+// timelineRecorder records the latencies of a worker in a timeline.
+// It's not safe for concurrent use.
+type timelineRecorder struct {
+	tl *timeline
+	i  int
+	h  *histogram.Histogram
+}
+
+func newTimeline(start time.Time, interval time.Duration) *timeline {
+	return &timeline{
+		start:    start,
+		interval: interval,
+		hists:    make(map[int]*histogram.Histogram),
+	}
+}
+
+// merge adds the latencies of h to the interval i.
+func (tl *timeline) merge(i int, h *histogram.Histogram) {
+	if h.Count() == 0 {
+		return
+	}
+
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+
+	th := tl.hists[i]
+	if th == nil {
+		th = histogram.New()
+		tl.hists[i] = th
+	}
+
+	th.Merge(h)
+}
+
+// mergeTimeline adds all the latencies of o.
+func (tl *timeline) mergeTimeline(o *timeline) {
+	for i, h := range o.hists {
+		tl.merge(i, h)
+	}
+}
+
+// samples returns the samples of every interval of a phase that took
+// the given duration, including the ones without operations.
+func (tl *timeline) samples(took time.Duration) []result.Sample {
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+
+	n := int((took + tl.interval - 1) / tl.interval)
+	for i := range tl.hists {
+		if i >= n {
+			n = i + 1
+		}
+	}
+
+	samples := make([]result.Sample, n)
+
+	for i := range samples {
+		s := &samples[i]
+		s.Offset = time.Duration(i) * tl.interval
+
+		span := tl.interval
+		if rest := took - s.Offset; rest > 0 && rest < span {
+			span = rest
+		}
+
+		h := tl.hists[i]
+		if h == nil {
+			continue
+		}
+
+		s.Ops = h.Count()
+		s.Throughput = float64(s.Ops) / span.Seconds()
+		s.Latency = h.Summary()
+	}
+
+	return samples
+}
+
+func (tl *timeline) newRecorder() *timelineRecorder {
+	return &timelineRecorder{
+		tl: tl,
+		h:  histogram.New(),
+	}
+}
+
+// record adds the latency d of an operation completed at end.
+func (r *timelineRecorder) record(end time.Time, d time.Duration) {
+	i := int(end.Sub(r.tl.start) / r.tl.interval)
+
+	if i != r.i {
+		r.flush()
+		r.i = i
+	}
+
+	r.h.Record(d)
+}
+
+// flush merges the latencies of the current interval into the timeline.
+func (r *timelineRecorder) flush() {
+	r.tl.merge(r.i, r.h)
+	r.h.Reset()
+}
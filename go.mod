@@ -3,11 +3,17 @@ module github.com/savsgio/kvbench
 go 1.16
 
 require (
+	github.com/VictoriaMetrics/fastcache v1.12.2
 	github.com/akrylysov/pogreb v0.10.1
+	github.com/allegro/bigcache/v3 v3.1.0
 	github.com/cockroachdb/pebble v0.0.0-20210622171231-4fcf40933159
+	github.com/coocood/freecache v1.2.4
 	github.com/dgraph-io/badger/v3 v3.2103.0
+	github.com/dgraph-io/ristretto v0.0.4-0.20210309073149-3836124cdc5a
 	github.com/savsgio/gotils v0.0.0-20210617111740-97865ed5a873
 	github.com/syndtr/goleveldb v1.0.1-0.20210305035536-64b5b1c73954
 	github.com/tidwall/buntdb v1.2.4
 	github.com/xujiajun/nutsdb v0.6.0
+	go.etcd.io/bbolt v1.3.6
+	modernc.org/sqlite v1.17.3
 )
@@ -3,6 +3,11 @@ package common
 type KV struct {
 	Key   []byte
 	Value []byte
+
+	// Found reports whether the key exists, as set by GetBulk.
+	Found bool
 }
 
 type IterFunc func(key, value []byte) error
+
+type ViewFunc func(value []byte) error
@@ -0,0 +1,169 @@
+// Package generator implements the generators of the benchmark data,
+// such as the distributions used to choose the keys of each operation.
+package generator
+
+import (
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+)
+
+// Key distributions.
+const (
+	Uniform    = "uniform"
+	Zipfian    = "zipfian"
+	Latest     = "latest"
+	Sequential = "sequential"
+	Hotspot    = "hotspot"
+)
+
+// DefaultSkew is the YCSB default zipfian constant.
+const DefaultSkew = 0.99
+
+// Chooser picks the index of the record of each operation.
+//
+// Implementations must be safe for concurrent use, given that every
+// goroutine uses its own r.
+type Chooser interface {
+	// Next returns an index in [0, n), where n is the current number of
+	// records of the key space.
+	Next(r *rand.Rand, n uint64) uint64
+}
+
+// Distributions returns the names of all the key distributions.
+func Distributions() []string {
+	return []string{Uniform, Zipfian, Latest, Sequential, Hotspot}
+}
+
+// NewChooser returns a chooser of the given distribution for a key space
+// of records items.
+//
+// The skew is the zipfian constant in (0, 1) for the zipfian and latest
+// distributions, and the fraction of the operations in (0, 1) that go to
+// the hot set for the hotspot distribution, where the hot set is the
+// remaining fraction of the records (e.g. 0.8 sends 80% of the operations
+// to 20% of the records).
+func NewChooser(dist string, records uint64, skew float64) (Chooser, error) {
+	switch dist {
+	case Uniform:
+		return uniform{}, nil
+	case Sequential:
+		return new(sequential), nil
+	case Zipfian, Latest:
+		if skew <= 0 || skew >= 1 {
+			return nil, fmt.Errorf("invalid %s skew: %v, must be in (0, 1)", dist, skew)
+		}
+
+		z := newZipfian(records, skew)
+		if dist == Latest {
+			return latest{z}, nil
+		}
+
+		return scrambledZipfian{z}, nil
+	case Hotspot:
+		if skew <= 0 || skew >= 1 {
+			return nil, fmt.Errorf("invalid %s skew: %v, must be in (0, 1)", dist, skew)
+		}
+
+		return hotspot{hotOps: skew, hotSet: 1 - skew}, nil
+	default:
+		return nil, fmt.Errorf("unknown key distribution: %v", dist)
+	}
+}
+
+type uniform struct{}
+
+func (uniform) Next(r *rand.Rand, n uint64) uint64 {
+	if n == 0 {
+		return 0
+	}
+
+	return uint64(r.Int63n(int64(n)))
+}
+
+// sequential goes through the key space in order, shared by all the
+// goroutines, and wraps around at the end.
+type sequential struct {
+	i uint64
+}
+
+func (s *sequential) Next(_ *rand.Rand, n uint64) uint64 {
+	i := atomic.AddUint64(&s.i, 1) - 1
+	if n == 0 {
+		return 0
+	}
+
+	return i % n
+}
+
+// scrambledZipfian spreads the popular items of a zipfian distribution
+// all over the key space, instead of clustering them at the beginning.
+type scrambledZipfian struct {
+	z *zipfian
+}
+
+func (s scrambledZipfian) Next(r *rand.Rand, n uint64) uint64 {
+	if n == 0 {
+		return 0
+	}
+
+	return fnvHash64(s.z.next(r)) % n
+}
+
+// latest favors the most recently inserted records.
+type latest struct {
+	z *zipfian
+}
+
+func (l latest) Next(r *rand.Rand, n uint64) uint64 {
+	if n == 0 {
+		return 0
+	}
+
+	i := l.z.next(r)
+	if i >= n {
+		i = n - 1
+	}
+
+	return n - 1 - i
+}
+
+type hotspot struct {
+	hotOps float64
+	hotSet float64
+}
+
+func (h hotspot) Next(r *rand.Rand, n uint64) uint64 {
+	if n == 0 {
+		return 0
+	}
+
+	hot := uint64(float64(n) * h.hotSet)
+	if hot == 0 {
+		hot = 1
+	}
+
+	if hot >= n || r.Float64() < h.hotOps {
+		return uint64(r.Int63n(int64(hot)))
+	}
+
+	return hot + uint64(r.Int63n(int64(n-hot)))
+}
+
+const (
+	fnvOffsetBasis64 = 0xcbf29ce484222325
+	fnvPrime64       = 1099511628211
+)
+
+// fnvHash64 returns the FNV-1a hash of the bytes of v.
+func fnvHash64(v uint64) uint64 {
+	h := uint64(fnvOffsetBasis64)
+
+	for i := 0; i < 8; i++ {
+		h ^= v & 0xff
+		h *= fnvPrime64
+		v >>= 8
+	}
+
+	return h
+}
@@ -0,0 +1,97 @@
+package generator
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestChooserRange(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for _, dist := range Distributions() {
+		ch, err := NewChooser(dist, 1000, 0.9)
+		if err != nil {
+			t.Fatalf("%s: %v", dist, err)
+		}
+
+		for _, n := range []uint64{1, 10, 1000, 5000} {
+			for i := 0; i < 10000; i++ {
+				if v := ch.Next(r, n); v >= n {
+					t.Fatalf("%s: %d out of range [0, %d)", dist, v, n)
+				}
+			}
+		}
+	}
+
+	if _, err := NewChooser("foo", 1000, 0.9); err == nil {
+		t.Error("expected error of unknown distribution")
+	}
+
+	if _, err := NewChooser(Zipfian, 1000, 1); err == nil {
+		t.Error("expected error of invalid skew")
+	}
+}
+
+func TestChooserSkew(t *testing.T) {
+	const n = 1000
+	const ops = 100000
+
+	r := rand.New(rand.NewSource(1))
+
+	tests := []struct {
+		dist string
+		hot  func(i uint64) bool
+		min  float64
+	}{
+		{Zipfian, nil, 0.5},
+		{Latest, func(i uint64) bool { return i >= n-n/10 }, 0.5},
+		{Hotspot, func(i uint64) bool { return i < n/5 }, 0.75},
+	}
+
+	for _, test := range tests {
+		ch, err := NewChooser(test.dist, n, 0.8)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		counts := make([]int, n)
+		for i := 0; i < ops; i++ {
+			counts[ch.Next(r, n)]++
+		}
+
+		hits := 0
+
+		if test.hot == nil {
+			// The popular items are scrambled, so take the 10% most accessed.
+			top := append([]int(nil), counts...)
+			for i := 0; i < n/10; i++ {
+				m := i
+				for j := i + 1; j < n; j++ {
+					if top[j] > top[m] {
+						m = j
+					}
+				}
+
+				top[i], top[m] = top[m], top[i]
+				hits += top[i]
+			}
+		} else {
+			for i := range counts {
+				if test.hot(uint64(i)) {
+					hits += counts[i]
+				}
+			}
+		}
+
+		if p := float64(hits) / ops; p < test.min {
+			t.Errorf("%s: hot fraction == %v, want >= %v", test.dist, p, test.min)
+		}
+	}
+
+	seq, _ := NewChooser(Sequential, n, 0)
+	for i := uint64(0); i < 2*n; i++ {
+		if v := seq.Next(r, n); v != i%n {
+			t.Fatalf("sequential == %d, want %d", v, i%n)
+		}
+	}
+}
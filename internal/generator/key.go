@@ -0,0 +1,173 @@
+package generator
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+)
+
+// Key formats.
+const (
+	KeyBinary  = "binary"
+	KeyDecimal = "decimal"
+	KeyHashed  = "hashed"
+	KeyTenant  = "tenant"
+)
+
+// DefaultKeySize is the size of the binary keys without padding.
+const DefaultKeySize = 9
+
+const (
+	keyPrefix    = 'k'
+	tenantPrefix = 't'
+	tenantSep    = '/'
+)
+
+// KeyEncoder builds the key of the i-th record.
+//
+// Encoders are deterministic, so the same index always yields the same
+// key, and safe for concurrent use.
+type KeyEncoder interface {
+	Key(i uint64) []byte
+}
+
+// KeyFormats returns the names of all the key formats.
+func KeyFormats() []string {
+	return []string{KeyBinary, KeyDecimal, KeyHashed, KeyTenant}
+}
+
+// NewKeyEncoder returns an encoder of keys of the given format and size:
+//
+//   - binary: 'k' and the big-endian index, padded with zeros after the 'k'.
+//   - decimal: 'k' and the zero-padded decimal index.
+//   - hashed: the bytes of a bijective hash of the index, followed by
+//     pseudo-random bytes derived from it.
+//   - tenant: 't', the zero-padded tenant (index % tenants), '/' and the
+//     zero-padded decimal index within the tenant.
+//
+// The decimal and tenant keys grow beyond the size if the index doesn't
+// fit into it, so they are still unique.
+func NewKeyEncoder(format string, size, tenants int) (KeyEncoder, error) {
+	switch format {
+	case KeyBinary:
+		if size < 9 {
+			return nil, fmt.Errorf("invalid %s key size: %d, must be at least 9", format, size)
+		}
+
+		return binaryKey{size: size}, nil
+	case KeyDecimal:
+		if size < 2 {
+			return nil, fmt.Errorf("invalid %s key size: %d, must be at least 2", format, size)
+		}
+
+		return decimalKey{size: size}, nil
+	case KeyHashed:
+		if size < 8 {
+			return nil, fmt.Errorf("invalid %s key size: %d, must be at least 8", format, size)
+		}
+
+		return hashedKey{size: size}, nil
+	case KeyTenant:
+		if tenants < 1 {
+			return nil, fmt.Errorf("invalid number of tenants: %d", tenants)
+		}
+
+		k := tenantKey{
+			size:         size,
+			tenants:      uint64(tenants),
+			tenantDigits: len(strconv.Itoa(tenants - 1)),
+		}
+
+		if min := k.tenantDigits + 3; size < min {
+			return nil, fmt.Errorf("invalid %s key size: %d, must be at least %d", format, size, min)
+		}
+
+		return k, nil
+	default:
+		return nil, fmt.Errorf("unknown key format: %v", format)
+	}
+}
+
+type binaryKey struct {
+	size int
+}
+
+func (k binaryKey) Key(i uint64) []byte {
+	r := make([]byte, k.size)
+	r[0] = keyPrefix
+	binary.BigEndian.PutUint64(r[k.size-8:], i)
+
+	return r
+}
+
+type decimalKey struct {
+	size int
+}
+
+// appendPadded appends the decimal i zero-padded to width digits.
+func appendPadded(dst []byte, i uint64, width int) []byte {
+	var buf [20]byte
+
+	d := strconv.AppendUint(buf[:0], i, 10)
+
+	for n := len(d); n < width; n++ {
+		dst = append(dst, '0')
+	}
+
+	return append(dst, d...)
+}
+
+func (k decimalKey) Key(i uint64) []byte {
+	r := make([]byte, 0, k.size)
+	r = append(r, keyPrefix)
+
+	return appendPadded(r, i, k.size-1)
+}
+
+type hashedKey struct {
+	size int
+}
+
+func (k hashedKey) Key(i uint64) []byte {
+	r := make([]byte, k.size)
+	h := mix64(i)
+
+	binary.BigEndian.PutUint64(r, h)
+
+	for j := 8; j < k.size; j += 8 {
+		h = mix64(h)
+
+		var buf [8]byte
+		binary.BigEndian.PutUint64(buf[:], h)
+		copy(r[j:], buf[:])
+	}
+
+	return r
+}
+
+type tenantKey struct {
+	size         int
+	tenants      uint64
+	tenantDigits int
+}
+
+func (k tenantKey) Key(i uint64) []byte {
+	r := make([]byte, 0, k.size)
+	r = append(r, tenantPrefix)
+	r = appendPadded(r, i%k.tenants, k.tenantDigits)
+	r = append(r, tenantSep)
+
+	return appendPadded(r, i/k.tenants, k.size-len(r))
+}
+
+// mix64 is the finalizer of MurmurHash3, which is a bijection of the
+// uint64 values, so different indexes never collide.
+func mix64(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+
+	return h
+}
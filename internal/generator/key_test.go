@@ -0,0 +1,66 @@
+package generator
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestKeyEncoder(t *testing.T) {
+	for _, format := range KeyFormats() {
+		for _, size := range []int{9, 16, 32, 128} {
+			enc, err := NewKeyEncoder(format, size, 16)
+			if err != nil {
+				t.Fatalf("%s/%d: %v", format, size, err)
+			}
+
+			seen := make(map[string]bool)
+
+			for i := uint64(0); i < 10000; i++ {
+				key := enc.Key(i)
+
+				if len(key) != size {
+					t.Fatalf("%s/%d: key %d has size %d", format, size, i, len(key))
+				}
+
+				if !bytes.Equal(key, enc.Key(i)) {
+					t.Fatalf("%s/%d: key %d is not deterministic", format, size, i)
+				}
+
+				if seen[string(key)] {
+					t.Fatalf("%s/%d: duplicated key %d: %q", format, size, i, key)
+				}
+
+				seen[string(key)] = true
+			}
+		}
+	}
+}
+
+func TestKeyEncoderFormat(t *testing.T) {
+	tests := []struct {
+		format string
+		size   int
+		i      uint64
+		want   string
+	}{
+		{KeyBinary, 9, 258, "k\x00\x00\x00\x00\x00\x00\x01\x02"},
+		{KeyDecimal, 8, 42, "k0000042"},
+		{KeyDecimal, 3, 1234, "k1234"},
+		{KeyTenant, 12, 42, "t10/00000002"},
+	}
+
+	for _, test := range tests {
+		enc, err := NewKeyEncoder(test.format, test.size, 16)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := string(enc.Key(test.i)); got != test.want {
+			t.Errorf("%s/%d: key %d == %q, want %q", test.format, test.size, test.i, got, test.want)
+		}
+	}
+
+	if _, err := NewKeyEncoder(KeyBinary, 8, 1); err == nil {
+		t.Error("expected error of too short binary key")
+	}
+}
@@ -0,0 +1,326 @@
+package generator
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Value size distributions.
+const (
+	ValueFixed     = "fixed"
+	ValueUniform   = "uniform"
+	ValueNormal    = "normal"
+	ValueHistogram = "hist"
+)
+
+// DefaultCompressibility is the fraction of repeated bytes of the values
+// by default, as in the db_bench of LevelDB.
+const DefaultCompressibility = 0.5
+
+const (
+	// Minimum size of the buffer the values are taken from.
+	minValueBufferSize = 1 << 20
+
+	// Size of the chunks of the buffer, made of random bytes repeated to
+	// reach its compressibility.
+	valueChunkSize = 100
+
+	// Number of standard deviations above the mean of the largest value
+	// of the normal distribution.
+	normalMaxDeviations = 4
+)
+
+// ValueSizer picks the size of each value.
+//
+// Implementations must be safe for concurrent use, given that every
+// goroutine uses its own r.
+type ValueSizer interface {
+	Next(r *rand.Rand) int
+
+	// Mean returns the expected size of the values.
+	Mean() int
+
+	// Max returns the largest size of the values.
+	Max() int
+
+	// String returns the spec of the distribution.
+	String() string
+}
+
+// ParseValueSizer returns the value size distribution defined by spec:
+//
+//   - N: fixed size of N bytes.
+//   - uniform:MIN-MAX: uniform size in [MIN, MAX].
+//   - normal:MEAN:STDDEV: normal size, clamped to [0, MEAN+4*STDDEV].
+//   - hist:FILE: sizes from a histogram file, with a "SIZE WEIGHT" pair
+//     per line (blank lines and lines starting with # are ignored).
+func ParseValueSizer(spec string) (ValueSizer, error) {
+	parts := strings.SplitN(spec, ":", 2)
+	if len(parts) == 1 {
+		n, err := strconv.Atoi(spec)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid value size: %v", spec)
+		}
+
+		return fixedSize(n), nil
+	}
+
+	dist, args := parts[0], parts[1]
+
+	switch dist {
+	case ValueFixed:
+		return ParseValueSizer(args)
+	case ValueUniform:
+		bounds := strings.SplitN(args, "-", 2)
+		if len(bounds) != 2 {
+			return nil, fmt.Errorf("invalid uniform value size, expected uniform:MIN-MAX: %v", spec)
+		}
+
+		min, err1 := strconv.Atoi(bounds[0])
+		max, err2 := strconv.Atoi(bounds[1])
+
+		if err1 != nil || err2 != nil || min < 0 || max < min {
+			return nil, fmt.Errorf("invalid uniform value size bounds: %v", spec)
+		}
+
+		return uniformSize{min: min, max: max}, nil
+	case ValueNormal:
+		params := strings.SplitN(args, ":", 2)
+		if len(params) != 2 {
+			return nil, fmt.Errorf("invalid normal value size, expected normal:MEAN:STDDEV: %v", spec)
+		}
+
+		mean, err1 := strconv.Atoi(params[0])
+		stddev, err2 := strconv.Atoi(params[1])
+
+		if err1 != nil || err2 != nil || mean < 0 || stddev < 0 {
+			return nil, fmt.Errorf("invalid normal value size parameters: %v", spec)
+		}
+
+		return normalSize{mean: mean, stddev: stddev}, nil
+	case ValueHistogram:
+		return readHistogramSize(args)
+	default:
+		return nil, fmt.Errorf("unknown value size distribution: %v", dist)
+	}
+}
+
+type fixedSize int
+
+func (s fixedSize) Next(*rand.Rand) int { return int(s) }
+func (s fixedSize) Mean() int           { return int(s) }
+func (s fixedSize) Max() int            { return int(s) }
+func (s fixedSize) String() string      { return strconv.Itoa(int(s)) }
+
+type uniformSize struct {
+	min, max int
+}
+
+func (s uniformSize) Next(r *rand.Rand) int {
+	return s.min + r.Intn(s.max-s.min+1)
+}
+
+func (s uniformSize) Mean() int {
+	return (s.min + s.max) / 2
+}
+
+func (s uniformSize) Max() int {
+	return s.max
+}
+
+func (s uniformSize) String() string {
+	return fmt.Sprintf("%s:%d-%d", ValueUniform, s.min, s.max)
+}
+
+type normalSize struct {
+	mean, stddev int
+}
+
+func (s normalSize) Next(r *rand.Rand) int {
+	n := int(math.Round(r.NormFloat64()*float64(s.stddev) + float64(s.mean)))
+
+	switch {
+	case n < 0:
+		return 0
+	case n > s.Max():
+		return s.Max()
+	default:
+		return n
+	}
+}
+
+func (s normalSize) Mean() int {
+	return s.mean
+}
+
+func (s normalSize) Max() int {
+	return s.mean + normalMaxDeviations*s.stddev
+}
+
+func (s normalSize) String() string {
+	return fmt.Sprintf("%s:%d:%d", ValueNormal, s.mean, s.stddev)
+}
+
+type histogramSize struct {
+	path       string
+	sizes      []int
+	cumulative []float64
+	mean       int
+}
+
+func readHistogramSize(path string) (ValueSizer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	s := histogramSize{path: path}
+
+	var total, sum float64
+
+	scanner := bufio.NewScanner(f)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected \"SIZE WEIGHT\"", path, line)
+		}
+
+		size, err1 := strconv.Atoi(fields[0])
+		weight, err2 := strconv.ParseFloat(fields[1], 64)
+
+		if err1 != nil || err2 != nil || size < 0 || weight < 0 {
+			return nil, fmt.Errorf("%s:%d: invalid size or weight", path, line)
+		}
+
+		total += weight
+		sum += float64(size) * weight
+
+		s.sizes = append(s.sizes, size)
+		s.cumulative = append(s.cumulative, total)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if total <= 0 {
+		return nil, fmt.Errorf("%s: empty value size histogram", path)
+	}
+
+	s.mean = int(sum / total)
+
+	return s, nil
+}
+
+func (s histogramSize) Next(r *rand.Rand) int {
+	f := r.Float64() * s.cumulative[len(s.cumulative)-1]
+	i := sort.SearchFloat64s(s.cumulative, f)
+
+	// Skip the sizes without weight.
+	for i < len(s.sizes)-1 && s.cumulative[i] <= f {
+		i++
+	}
+
+	return s.sizes[i]
+}
+
+func (s histogramSize) Mean() int {
+	return s.mean
+}
+
+func (s histogramSize) Max() int {
+	max := 0
+
+	for _, size := range s.sizes {
+		if size > max {
+			max = size
+		}
+	}
+
+	return max
+}
+
+func (s histogramSize) String() string {
+	return ValueHistogram + ":" + s.path
+}
+
+// ValueGenerator generates values with the sizes of a distribution.
+//
+// The values are slices of a read-only buffer filled at creation, so they
+// are cheap to generate and must not be modified.
+type ValueGenerator struct {
+	sizer ValueSizer
+	buf   []byte
+}
+
+// NewValueGenerator returns a generator of values whose sizes are chosen
+// by sizer, where compressibility in [0, 1] is the fraction of repeated
+// bytes of the values (0 are random bytes, 1 are all repeated).
+func NewValueGenerator(sizer ValueSizer, compressibility float64, seed int64) (*ValueGenerator, error) {
+	if compressibility < 0 || compressibility > 1 {
+		return nil, fmt.Errorf("invalid compressibility: %v, must be in [0, 1]", compressibility)
+	}
+
+	size := 2 * sizer.Max()
+	if size < minValueBufferSize {
+		size = minValueBufferSize
+	}
+
+	r := rand.New(rand.NewSource(seed))
+	buf := make([]byte, size)
+
+	random := int(math.Ceil(valueChunkSize * (1 - compressibility)))
+	if random < 1 {
+		random = 1
+	}
+
+	for i := 0; i < len(buf); i += valueChunkSize {
+		chunk := buf[i:]
+		if len(chunk) > valueChunkSize {
+			chunk = chunk[:valueChunkSize]
+		}
+
+		n := random
+		if n > len(chunk) {
+			n = len(chunk)
+		}
+
+		r.Read(chunk[:n])
+
+		for j := n; j < len(chunk); j++ {
+			chunk[j] = chunk[j%n]
+		}
+	}
+
+	return &ValueGenerator{
+		sizer: sizer,
+		buf:   buf,
+	}, nil
+}
+
+// Sizer returns the value size distribution of the generator.
+func (g *ValueGenerator) Sizer() ValueSizer {
+	return g.sizer
+}
+
+// Next returns a value of the next size.
+func (g *ValueGenerator) Next(r *rand.Rand) []byte {
+	size := g.sizer.Next(r)
+	offset := r.Intn(len(g.buf) - size + 1)
+
+	return g.buf[offset : offset+size : offset+size]
+}
@@ -0,0 +1,111 @@
+package generator
+
+import (
+	"bytes"
+	"compress/flate"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseValueSizer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kvbench")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	hist := filepath.Join(dir, "sizes.txt")
+	if err := ioutil.WriteFile(hist, []byte("# size weight\n100 3\n\n1000 1\n5000 0\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		spec     string
+		mean     int
+		min, max int
+	}{
+		{"256", 256, 256, 256},
+		{"fixed:128", 128, 128, 128},
+		{"uniform:64-1024", 544, 64, 1024},
+		{"normal:512:128", 512, 0, 1024},
+		{"hist:" + hist, 325, 100, 1000},
+	}
+
+	r := rand.New(rand.NewSource(1))
+
+	for _, test := range tests {
+		sizer, err := ParseValueSizer(test.spec)
+		if err != nil {
+			t.Fatalf("%s: %v", test.spec, err)
+		}
+
+		if sizer.Mean() != test.mean {
+			t.Errorf("%s: mean == %d, want %d", test.spec, sizer.Mean(), test.mean)
+		}
+
+		sum := 0
+
+		for i := 0; i < 10000; i++ {
+			n := sizer.Next(r)
+			if n < test.min || n > test.max || n > sizer.Max() {
+				t.Fatalf("%s: size %d out of range [%d, %d]", test.spec, n, test.min, test.max)
+			}
+
+			sum += n
+		}
+
+		if mean := sum / 10000; mean < test.mean*9/10 || mean > test.mean*11/10 {
+			t.Errorf("%s: sampled mean == %d, want ~%d", test.spec, mean, test.mean)
+		}
+	}
+
+	for _, spec := range []string{"", "-1", "foo:1", "uniform:10", "uniform:10-1", "normal:1", "hist:/nonexistent"} {
+		if _, err := ParseValueSizer(spec); err == nil {
+			t.Errorf("expected error parsing %q", spec)
+		}
+	}
+}
+
+func compressedRatio(t *testing.T, compressibility float64) float64 {
+	sizer, _ := ParseValueSizer("4096")
+
+	g, err := NewValueGenerator(sizer, compressibility, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	value := g.Next(rand.New(rand.NewSource(1)))
+	if len(value) != 4096 {
+		t.Fatalf("value size == %d, want 4096", len(value))
+	}
+
+	var buf bytes.Buffer
+
+	w, _ := flate.NewWriter(&buf, flate.BestSpeed)
+	_, _ = w.Write(value)
+	w.Close()
+
+	return float64(buf.Len()) / float64(len(value))
+}
+
+func TestValueGeneratorCompressibility(t *testing.T) {
+	if r := compressedRatio(t, 0); r < 0.95 {
+		t.Errorf("random values compressed to %v", r)
+	}
+
+	if r := compressedRatio(t, 0.5); r < 0.4 || r > 0.7 {
+		t.Errorf("half compressible values compressed to %v", r)
+	}
+
+	if r := compressedRatio(t, 1); r > 0.1 {
+		t.Errorf("repeated values compressed to %v", r)
+	}
+
+	if _, err := NewValueGenerator(fixedSize(1), 2, 1); err == nil {
+		t.Error("expected error of invalid compressibility")
+	}
+}
@@ -0,0 +1,67 @@
+package generator
+
+import (
+	"math"
+	"math/rand"
+)
+
+// zipfian generates items in [0, items) following a zipfian distribution,
+// where the lower the item the more popular it is.
+//
+// It's the algorithm of "Quickly Generating Billion-Record Synthetic
+// Databases" by Gray et al, as used by YCSB.
+type zipfian struct {
+	items float64
+	theta float64
+	alpha float64
+	zetan float64
+	eta   float64
+	half  float64
+}
+
+func zeta(n uint64, theta float64) float64 {
+	var sum float64
+
+	for i := uint64(1); i <= n; i++ {
+		sum += 1 / math.Pow(float64(i), theta)
+	}
+
+	return sum
+}
+
+func newZipfian(items uint64, theta float64) *zipfian {
+	if items < 2 {
+		items = 2
+	}
+
+	zetan := zeta(items, theta)
+	zeta2 := zeta(2, theta)
+
+	return &zipfian{
+		items: float64(items),
+		theta: theta,
+		alpha: 1 / (1 - theta),
+		zetan: zetan,
+		eta:   (1 - math.Pow(2/float64(items), 1-theta)) / (1 - zeta2/zetan),
+		half:  1 + math.Pow(0.5, theta),
+	}
+}
+
+func (z *zipfian) next(r *rand.Rand) uint64 {
+	u := r.Float64()
+	uz := u * z.zetan
+
+	switch {
+	case uz < 1:
+		return 0
+	case uz < z.half:
+		return 1
+	}
+
+	i := uint64(z.items * math.Pow(z.eta*u-z.eta+1, z.alpha))
+	if i >= uint64(z.items) {
+		i = uint64(z.items) - 1
+	}
+
+	return i
+}
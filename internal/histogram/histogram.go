@@ -0,0 +1,202 @@
+// Package histogram implements a fixed-size log-linear latency histogram
+// in the spirit of HdrHistogram.
+//
+// Values are bucketed by their power of two and subdivided linearly into
+// 64 sub-buckets, which keeps the relative error below 1.6% for any value
+// while recording in constant time without allocations.
+package histogram
+
+import (
+	"math"
+	"math/bits"
+	"time"
+)
+
+const (
+	subBucketBits  = 7
+	subBucketCount = 1 << subBucketBits
+	subBucketHalf  = subBucketCount / 2
+
+	numBuckets = (64-subBucketBits)*subBucketHalf + subBucketCount
+)
+
+// Histogram records durations. It's not safe for concurrent use,
+// so record in one histogram per goroutine and Merge them at the end.
+type Histogram struct {
+	counts [numBuckets]uint64
+	count  uint64
+	sum    uint64
+	min    int64
+	max    int64
+}
+
+// Summary is a snapshot of the main statistics of a histogram.
+type Summary struct {
+	Count uint64        `json:"count"`
+	Min   time.Duration `json:"min_ns"`
+	Mean  time.Duration `json:"mean_ns"`
+	P50   time.Duration `json:"p50_ns"`
+	P90   time.Duration `json:"p90_ns"`
+	P99   time.Duration `json:"p99_ns"`
+	P999  time.Duration `json:"p999_ns"`
+	Max   time.Duration `json:"max_ns"`
+}
+
+// New returns an empty histogram.
+func New() *Histogram {
+	h := new(Histogram)
+	h.Reset()
+
+	return h
+}
+
+func bucketIndex(v int64) int {
+	u := uint64(v)
+	if u < subBucketCount {
+		return int(u)
+	}
+
+	shift := bits.Len64(u) - subBucketBits
+
+	return shift*subBucketHalf + int(u>>uint(shift))
+}
+
+// bucketValue returns the highest value that falls into the bucket i.
+func bucketValue(i int) int64 {
+	if i < subBucketCount {
+		return int64(i)
+	}
+
+	shift := i/subBucketHalf - 1
+	mantissa := uint64(i - shift*subBucketHalf)
+
+	return int64((mantissa+1)<<uint(shift) - 1)
+}
+
+// Record adds the duration d to the histogram. Negative durations are
+// recorded as zero.
+func (h *Histogram) Record(d time.Duration) {
+	v := int64(d)
+	if v < 0 {
+		v = 0
+	}
+
+	h.counts[bucketIndex(v)]++
+	h.count++
+	h.sum += uint64(v)
+
+	if v < h.min {
+		h.min = v
+	}
+
+	if v > h.max {
+		h.max = v
+	}
+}
+
+// Merge adds all the values recorded in o to the histogram.
+func (h *Histogram) Merge(o *Histogram) {
+	if o.count == 0 {
+		return
+	}
+
+	for i := range o.counts {
+		h.counts[i] += o.counts[i]
+	}
+
+	h.count += o.count
+	h.sum += o.sum
+
+	if o.min < h.min {
+		h.min = o.min
+	}
+
+	if o.max > h.max {
+		h.max = o.max
+	}
+}
+
+// Reset removes all the recorded values.
+func (h *Histogram) Reset() {
+	h.counts = [numBuckets]uint64{}
+	h.count = 0
+	h.sum = 0
+	h.min = math.MaxInt64
+	h.max = 0
+}
+
+// Count returns the number of recorded values.
+func (h *Histogram) Count() uint64 {
+	return h.count
+}
+
+// Min returns the lowest recorded value.
+func (h *Histogram) Min() time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+
+	return time.Duration(h.min)
+}
+
+// Max returns the highest recorded value.
+func (h *Histogram) Max() time.Duration {
+	return time.Duration(h.max)
+}
+
+// Mean returns the exact mean of the recorded values.
+func (h *Histogram) Mean() time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+
+	return time.Duration(h.sum / h.count)
+}
+
+// Percentile returns the value below which the p percent (0-100) of the
+// recorded values fall.
+func (h *Histogram) Percentile(p float64) time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+
+	if p >= 100 {
+		return h.Max()
+	}
+
+	rank := uint64(math.Ceil(p / 100 * float64(h.count)))
+	if rank == 0 {
+		rank = 1
+	}
+
+	var n uint64
+
+	for i := range h.counts {
+		n += h.counts[i]
+
+		if n >= rank {
+			v := bucketValue(i)
+			if v > h.max {
+				v = h.max
+			}
+
+			return time.Duration(v)
+		}
+	}
+
+	return h.Max()
+}
+
+// Summary returns the main statistics of the histogram.
+func (h *Histogram) Summary() Summary {
+	return Summary{
+		Count: h.count,
+		Min:   h.Min(),
+		Mean:  h.Mean(),
+		P50:   h.Percentile(50),
+		P90:   h.Percentile(90),
+		P99:   h.Percentile(99),
+		P999:  h.Percentile(99.9),
+		Max:   h.Max(),
+	}
+}
@@ -0,0 +1,87 @@
+package histogram
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBucketRoundTrip(t *testing.T) {
+	for _, v := range []int64{0, 1, 127, 128, 255, 256, 1000, 123456789, 1 << 40, 1<<62 + 12345} {
+		i := bucketIndex(v)
+		if i < 0 || i >= numBuckets {
+			t.Fatalf("index out of range for %d: %d", v, i)
+		}
+
+		hi := bucketValue(i)
+		if hi < v {
+			t.Fatalf("bucket upper bound %d lower than value %d", hi, v)
+		}
+
+		if i > 0 && bucketValue(i-1) >= v {
+			t.Fatalf("value %d should fall in a previous bucket than %d", v, i)
+		}
+
+		if v > 0 && float64(hi-v)/float64(v) > 0.016 {
+			t.Fatalf("relative error too high for %d: %d", v, hi)
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	h := New()
+
+	for i := 1; i <= 10000; i++ {
+		h.Record(time.Duration(i) * time.Microsecond)
+	}
+
+	tests := []struct {
+		p    float64
+		want time.Duration
+	}{
+		{50, 5000 * time.Microsecond},
+		{90, 9000 * time.Microsecond},
+		{99, 9900 * time.Microsecond},
+		{99.9, 9990 * time.Microsecond},
+		{100, 10000 * time.Microsecond},
+	}
+
+	for _, test := range tests {
+		got := h.Percentile(test.p)
+
+		diff := float64(got-test.want) / float64(test.want)
+		if diff < 0 || diff > 0.016 {
+			t.Errorf("p%v == %v, want %v", test.p, got, test.want)
+		}
+	}
+
+	if h.Min() != time.Microsecond {
+		t.Errorf("min == %v, want %v", h.Min(), time.Microsecond)
+	}
+
+	if h.Max() != 10000*time.Microsecond {
+		t.Errorf("max == %v, want %v", h.Max(), 10000*time.Microsecond)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	a, b := New(), New()
+
+	a.Record(time.Millisecond)
+	b.Record(3 * time.Millisecond)
+	b.Record(5 * time.Millisecond)
+
+	a.Merge(b)
+	a.Merge(New())
+
+	if a.Count() != 3 {
+		t.Fatalf("count == %d, want 3", a.Count())
+	}
+
+	if a.Mean() != 3*time.Millisecond {
+		t.Errorf("mean == %v, want %v", a.Mean(), 3*time.Millisecond)
+	}
+
+	if a.Min() != time.Millisecond || a.Max() != 5*time.Millisecond {
+		t.Errorf("min/max == %v/%v", a.Min(), a.Max())
+	}
+}
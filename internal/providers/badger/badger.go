@@ -17,6 +17,15 @@ type DB struct {
 	mu    sync.RWMutex
 }
 
+func init() {
+	store.Register(store.Provider{
+		Name:   "badger",
+		Path:   "badger.db",
+		Memory: true,
+		New:    New,
+	})
+}
+
 func New(path string, fsync bool) (store.DB, error) {
 	db := &DB{
 		path:  path,
@@ -34,8 +43,9 @@ func (db *DB) init() error {
 	opts := badger.DefaultOptions(db.path)
 	opts.SyncWrites = db.fsync
 
-	if db.path == ":memory:" {
-		opts.InMemory = true
+	// Badger refuses a directory in memory.
+	if db.path == store.MemoryPath {
+		opts = badger.DefaultOptions("").WithInMemory(true)
 	}
 
 	bdb, err := badger.Open(opts)
@@ -103,7 +113,7 @@ func (db *DB) Get(key []byte) (value []byte, err error) {
 
 		switch {
 		case err != nil && errors.Is(err, badger.ErrKeyNotFound):
-			return nil
+			return store.ErrNotFound
 		case err != nil:
 			return err
 		}
@@ -125,6 +135,28 @@ func (db *DB) GetString(key string) ([]byte, error) {
 	return db.Get(strconv.S2B(key))
 }
 
+func (db *DB) View(key []byte, fn common.ViewFunc) error {
+	if len(key) == 0 {
+		return store.ErrEmptyKey
+	}
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	return db.db.View(func(tx *badger.Txn) error {
+		item, err := tx.Get(key)
+
+		switch {
+		case err != nil && errors.Is(err, badger.ErrKeyNotFound):
+			return store.ErrNotFound
+		case err != nil:
+			return err
+		}
+
+		return item.Value(fn)
+	})
+}
+
 func (db *DB) GetBulk(keys ...[]byte) ([]common.KV, error) {
 	db.mu.RLock()
 	defer db.mu.RUnlock()
@@ -155,6 +187,8 @@ func (db *DB) GetBulk(keys ...[]byte) ([]common.KV, error) {
 			if err != nil {
 				return err
 			}
+
+			kv.Found = true
 		}
 
 		return nil
@@ -229,13 +263,73 @@ func (db *DB) Iter(fn common.IterFunc) error {
 	})
 }
 
-func (db *DB) Flush() error {
-	db.mu.Lock()
-	defer db.mu.Unlock()
+func (db *DB) IterRange(r store.Range, fn common.IterFunc) error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	return db.db.View(func(tx *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Reverse = r.Reverse
+
+		if r.Limit > 0 && r.Limit < opts.PrefetchSize {
+			opts.PrefetchSize = r.Limit
+		}
+
+		it := tx.NewIterator(opts)
+		defer it.Close()
+
+		// In reverse, Seek moves to the last key before or equal to the
+		// pivot, so the end of the range is skipped below.
+		pivot := r.Start
+		if r.Reverse {
+			pivot = r.End
+		}
+
+		if len(pivot) > 0 {
+			it.Seek(pivot)
+		} else {
+			it.Rewind()
+		}
+
+		for n := 0; it.Valid() && !r.Full(n); it.Next() {
+			item := it.Item()
+			key := item.Key()
+
+			if r.Past(key) {
+				break
+			} else if !r.Contains(key) {
+				continue
+			}
+
+			value, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+
+			if err := fn(key, value); err != nil {
+				return err
+			}
+
+			n++
+		}
+
+		return nil
+	})
+}
+
+func (db *DB) Sync() error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
 
 	return db.db.Sync()
 }
 
+// Flush syncs the store, since badger doesn't provide a way to flush
+// the memtable, other than closing the store.
+func (db *DB) Flush() error {
+	return db.Sync()
+}
+
 func (db *DB) Reset() error {
 	db.mu.Lock()
 	defer db.mu.Unlock()
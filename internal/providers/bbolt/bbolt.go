@@ -0,0 +1,288 @@
+package bbolt
+
+import (
+	"github.com/savsgio/gotils/strconv"
+	"github.com/savsgio/kvbench/internal/common"
+	"github.com/savsgio/kvbench/internal/store"
+	bolt "go.etcd.io/bbolt"
+)
+
+var bucket = []byte("kvbench")
+
+type DB struct {
+	path  string
+	fsync bool
+	db    *bolt.DB
+}
+
+func init() {
+	store.Register(store.Provider{
+		Name: "bbolt",
+		Path: "bbolt.db",
+		New:  New,
+	})
+}
+
+func New(path string, fsync bool) (store.DB, error) {
+	db := &DB{
+		path:  path,
+		fsync: fsync,
+	}
+
+	if err := db.init(); err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
+
+func (db *DB) init() error {
+	if db.path == store.MemoryPath {
+		return store.ErrMemoryNotAllowed
+	}
+
+	opts := *bolt.DefaultOptions
+	opts.NoSync = !db.fsync
+
+	bdb, err := bolt.Open(db.path, 0o600, &opts)
+	if err != nil {
+		return err
+	}
+
+	db.db = bdb
+
+	if err := db.db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucket)
+
+		return err
+	}); err != nil {
+		bdb.Close()
+
+		return store.ErrInit
+	}
+
+	return nil
+}
+
+func (db *DB) Set(key, value []byte) error {
+	if len(key) == 0 {
+		return store.ErrEmptyKey
+	}
+
+	return db.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Put(key, value)
+	})
+}
+
+func (db *DB) SetString(key string, value []byte) error {
+	return db.Set(strconv.S2B(key), value)
+}
+
+// SetBulk writes the entries through db.Batch, which coalesces the
+// concurrent bulks in a single transaction, and may run fn more than once.
+func (db *DB) SetBulk(kvs ...common.KV) error {
+	for i := range kvs {
+		if len(kvs[i].Key) == 0 {
+			return store.ErrEmptyKey
+		}
+	}
+
+	return db.db.Batch(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+
+		for i := range kvs {
+			kv := kvs[i]
+
+			if err := b.Put(kv.Key, kv.Value); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (db *DB) Get(key []byte) (value []byte, err error) {
+	err = db.View(key, func(v []byte) error {
+		// The value is only valid until the transaction ends.
+		value = append([]byte{}, v...)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+func (db *DB) GetString(key string) ([]byte, error) {
+	return db.Get(strconv.S2B(key))
+}
+
+func (db *DB) View(key []byte, fn common.ViewFunc) error {
+	if len(key) == 0 {
+		return store.ErrEmptyKey
+	}
+
+	return db.db.View(func(tx *bolt.Tx) error {
+		// bbolt returns a nil value only for a missing key.
+		v := tx.Bucket(bucket).Get(key)
+		if v == nil {
+			return store.ErrNotFound
+		}
+
+		return fn(v)
+	})
+}
+
+func (db *DB) GetBulk(keys ...[]byte) ([]common.KV, error) {
+	kvs := make([]common.KV, len(keys))
+
+	err := db.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+
+		for i := range keys {
+			key := keys[i]
+
+			if len(key) == 0 {
+				return store.ErrEmptyKey
+			}
+
+			kv := &kvs[i]
+			kv.Key = append(kv.Key, key...)
+
+			v := b.Get(key)
+			if v == nil {
+				continue
+			}
+
+			kv.Value = append(kv.Value, v...)
+			kv.Found = true
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return kvs, nil
+}
+
+func (db *DB) Del(key []byte) error {
+	if len(key) == 0 {
+		return store.ErrEmptyKey
+	}
+
+	return db.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Delete(key)
+	})
+}
+
+func (db *DB) DelString(key string) error {
+	return db.Del(strconv.S2B(key))
+}
+
+func (db *DB) DelBulk(keys ...[]byte) error {
+	return db.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+
+		for i := range keys {
+			key := keys[i]
+
+			if len(key) == 0 {
+				return store.ErrEmptyKey
+			}
+
+			if err := b.Delete(key); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (db *DB) Iter(fn common.IterFunc) error {
+	return db.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucket).Cursor()
+
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			if err := fn(k, v); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (db *DB) IterRange(r store.Range, fn common.IterFunc) error {
+	return db.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucket).Cursor()
+
+		var k, v []byte
+
+		switch {
+		case r.Reverse && len(r.End) > 0:
+			// Seek moves to the first key after or equal to the end,
+			// which is out of the range.
+			if k, _ = c.Seek(r.End); k == nil {
+				k, v = c.Last()
+			} else {
+				k, v = c.Prev()
+			}
+		case r.Reverse:
+			k, v = c.Last()
+		case len(r.Start) > 0:
+			k, v = c.Seek(r.Start)
+		default:
+			k, v = c.First()
+		}
+
+		next := c.Next
+		if r.Reverse {
+			next = c.Prev
+		}
+
+		for n := 0; k != nil && !r.Full(n) && !r.Past(k); k, v = next() {
+			if err := fn(k, v); err != nil {
+				return err
+			}
+
+			n++
+		}
+
+		return nil
+	})
+}
+
+// Sync syncs the file of the store, which bbolt does regardless of its
+// NoSync option.
+func (db *DB) Sync() error {
+	return db.db.Sync()
+}
+
+// Flush syncs the store, since bbolt writes the pages of every commit
+// to its file.
+func (db *DB) Flush() error {
+	return db.Sync()
+}
+
+func (db *DB) Reset() error {
+	return db.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket(bucket); err != nil {
+			return err
+		}
+
+		_, err := tx.CreateBucket(bucket)
+
+		return err
+	})
+}
+
+func (db *DB) Close() error {
+	return db.db.Close()
+}
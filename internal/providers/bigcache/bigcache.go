@@ -0,0 +1,208 @@
+package bigcache
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/allegro/bigcache/v3"
+	"github.com/savsgio/gotils/strconv"
+	"github.com/savsgio/kvbench/internal/common"
+	"github.com/savsgio/kvbench/internal/store"
+)
+
+// sizeMB is the capacity of the cache in MB, beyond which each shard
+// evicts its oldest entries.
+const sizeMB = 128
+
+// DB is a bigcache, which keeps the entries in byte queues out of
+// the sight of the GC, indexed by the hash of their keys.
+type DB struct {
+	c *bigcache.BigCache
+}
+
+func init() {
+	store.Register(store.Provider{
+		Name:     "bigcache",
+		Memory:   true,
+		Volatile: true,
+		Lacks:    store.CapOrderedIter | store.CapRetention,
+		New:      New,
+	})
+}
+
+// New returns an empty cache, ignoring the path and the fsync flag.
+func New(path string, fsync bool) (store.DB, error) {
+	// The entries never expire, they're only evicted by the size.
+	config := bigcache.DefaultConfig(time.Duration(math.MaxInt64))
+	config.CleanWindow = 0
+	config.HardMaxCacheSize = sizeMB
+	config.Verbose = false
+
+	c, err := bigcache.New(context.Background(), config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DB{c: c}, nil
+}
+
+func (db *DB) Set(key, value []byte) error {
+	if len(key) == 0 {
+		return store.ErrEmptyKey
+	}
+
+	return db.c.Set(strconv.B2S(key), value)
+}
+
+func (db *DB) SetString(key string, value []byte) error {
+	return db.Set(strconv.S2B(key), value)
+}
+
+func (db *DB) SetBulk(kvs ...common.KV) error {
+	for i := range kvs {
+		if len(kvs[i].Key) == 0 {
+			return store.ErrEmptyKey
+		}
+	}
+
+	for i := range kvs {
+		if err := db.c.Set(strconv.B2S(kvs[i].Key), kvs[i].Value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Get returns a copy of the value, since bigcache copies it out of its
+// queues.
+func (db *DB) Get(key []byte) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, store.ErrEmptyKey
+	}
+
+	v, err := db.c.Get(strconv.B2S(key))
+	if errors.Is(err, bigcache.ErrEntryNotFound) {
+		return nil, store.ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+func (db *DB) GetString(key string) ([]byte, error) {
+	return db.Get(strconv.S2B(key))
+}
+
+func (db *DB) View(key []byte, fn common.ViewFunc) error {
+	v, err := db.Get(key)
+	if err != nil {
+		return err
+	}
+
+	return fn(v)
+}
+
+func (db *DB) GetBulk(keys ...[]byte) ([]common.KV, error) {
+	kvs := make([]common.KV, len(keys))
+
+	for i := range keys {
+		key := keys[i]
+
+		if len(key) == 0 {
+			return nil, store.ErrEmptyKey
+		}
+
+		kv := &kvs[i]
+		kv.Key = append(kv.Key, key...)
+
+		v, err := db.c.Get(strconv.B2S(key))
+		if errors.Is(err, bigcache.ErrEntryNotFound) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		kv.Value = v
+		kv.Found = true
+	}
+
+	return kvs, nil
+}
+
+// Del deletes the entry with the hash of the key, which may be other
+// key on a collision.
+func (db *DB) Del(key []byte) error {
+	if len(key) == 0 {
+		return store.ErrEmptyKey
+	}
+
+	err := db.c.Delete(strconv.B2S(key))
+	if errors.Is(err, bigcache.ErrEntryNotFound) {
+		return nil
+	}
+
+	return err
+}
+
+func (db *DB) DelString(key string) error {
+	return db.Del(strconv.S2B(key))
+}
+
+func (db *DB) DelBulk(keys ...[]byte) error {
+	for i := range keys {
+		if len(keys[i]) == 0 {
+			return store.ErrEmptyKey
+		}
+	}
+
+	for i := range keys {
+		if err := db.Del(keys[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Iter calls fn with the entries in no order, copying the entries of
+// each shard in turn.
+func (db *DB) Iter(fn common.IterFunc) error {
+	it := db.c.Iterator()
+
+	for it.SetNext() {
+		e, err := it.Value()
+		if err != nil {
+			return err
+		}
+
+		if err := fn(strconv.S2B(e.Key()), e.Value()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (db *DB) IterRange(r store.Range, fn common.IterFunc) error {
+	return store.IterUnordered(r, db.Iter, fn)
+}
+
+func (db *DB) Sync() error {
+	return nil
+}
+
+func (db *DB) Flush() error {
+	return nil
+}
+
+func (db *DB) Reset() error {
+	return db.c.Reset()
+}
+
+func (db *DB) Close() error {
+	return db.c.Close()
+}
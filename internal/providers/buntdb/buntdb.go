@@ -2,6 +2,7 @@ package buntdb
 
 import (
 	"errors"
+	"os"
 
 	"github.com/savsgio/gotils/strconv"
 	"github.com/savsgio/kvbench/internal/common"
@@ -15,6 +16,15 @@ type DB struct {
 	db    *buntdb.DB
 }
 
+func init() {
+	store.Register(store.Provider{
+		Name:   "buntdb",
+		Path:   "buntdb.db",
+		Memory: true,
+		New:    New,
+	})
+}
+
 func New(path string, fsync bool) (store.DB, error) {
 	db := &DB{
 		path:  path,
@@ -28,11 +38,9 @@ func New(path string, fsync bool) (store.DB, error) {
 	return db, nil
 }
 
+// init opens the store, which buntdb keeps in memory alone with the
+// MemoryPath.
 func (db *DB) init() error {
-	if db.path == ":memory:" {
-		return store.ErrMemoryNotAllowed
-	}
-
 	opts := buntdb.Config{}
 	if db.fsync {
 		opts.SyncPolicy = buntdb.Always
@@ -57,6 +65,10 @@ func (db *DB) Set(key, value []byte) error {
 }
 
 func (db *DB) SetString(key string, value []byte) error {
+	if len(key) == 0 {
+		return store.ErrEmptyKey
+	}
+
 	return db.db.Update(func(tx *buntdb.Tx) error {
 		_, _, err := tx.Set(key, strconv.B2S(value), nil)
 
@@ -69,6 +81,10 @@ func (db *DB) SetBulk(kvs ...common.KV) error {
 		for i := range kvs {
 			kv := kvs[i]
 
+			if len(kv.Key) == 0 {
+				return store.ErrEmptyKey
+			}
+
 			if _, _, err := tx.Set(strconv.B2S(kv.Key), strconv.B2S(kv.Value), nil); err != nil {
 				return err
 			}
@@ -83,12 +99,16 @@ func (db *DB) Get(key []byte) (val []byte, err error) {
 }
 
 func (db *DB) GetString(key string) (value []byte, err error) {
+	if len(key) == 0 {
+		return nil, store.ErrEmptyKey
+	}
+
 	err = db.db.View(func(tx *buntdb.Tx) error {
 		v, err := tx.Get(key)
 
 		switch {
 		case err != nil && errors.Is(err, buntdb.ErrNotFound):
-			return nil
+			return store.ErrNotFound
 		case err != nil:
 			return err
 		}
@@ -105,6 +125,25 @@ func (db *DB) GetString(key string) (value []byte, err error) {
 	return value, nil
 }
 
+func (db *DB) View(key []byte, fn common.ViewFunc) error {
+	if len(key) == 0 {
+		return store.ErrEmptyKey
+	}
+
+	return db.db.View(func(tx *buntdb.Tx) error {
+		v, err := tx.Get(strconv.B2S(key))
+
+		switch {
+		case err != nil && errors.Is(err, buntdb.ErrNotFound):
+			return store.ErrNotFound
+		case err != nil:
+			return err
+		}
+
+		return fn(strconv.S2B(v))
+	})
+}
+
 func (db *DB) GetBulk(keys ...[]byte) ([]common.KV, error) {
 	kvs := make([]common.KV, len(keys))
 
@@ -112,14 +151,24 @@ func (db *DB) GetBulk(keys ...[]byte) ([]common.KV, error) {
 		for i := range keys {
 			key := keys[i]
 
-			value, err := tx.Get(strconv.B2S(key))
-			if err != nil && !errors.Is(err, buntdb.ErrNotFound) {
-				return err
+			if len(key) == 0 {
+				return store.ErrEmptyKey
 			}
 
 			kv := &kvs[i]
 			kv.Key = append(kv.Key, key...)
+
+			value, err := tx.Get(strconv.B2S(key))
+
+			switch {
+			case err != nil && errors.Is(err, buntdb.ErrNotFound):
+				continue
+			case err != nil:
+				return err
+			}
+
 			kv.Value = append(kv.Value, value...)
+			kv.Found = true
 		}
 
 		return nil
@@ -137,6 +186,10 @@ func (db *DB) Del(key []byte) error {
 }
 
 func (db *DB) DelString(key string) error {
+	if len(key) == 0 {
+		return store.ErrEmptyKey
+	}
+
 	return db.db.Update(func(tx *buntdb.Tx) error {
 		_, err := tx.Delete(key)
 
@@ -151,6 +204,10 @@ func (db *DB) DelString(key string) error {
 func (db *DB) DelBulk(keys ...[]byte) error {
 	return db.db.Update(func(tx *buntdb.Tx) error {
 		for i := range keys {
+			if len(keys[i]) == 0 {
+				return store.ErrEmptyKey
+			}
+
 			_, err := tx.Delete(strconv.B2S(keys[i]))
 
 			switch {
@@ -181,7 +238,68 @@ func (db *DB) Iter(fn common.IterFunc) (err error) {
 	return err
 }
 
+func (db *DB) IterRange(r store.Range, fn common.IterFunc) (err error) {
+	n := 0
+
+	iter := func(key, value string) bool {
+		k := strconv.S2B(key)
+
+		if r.Past(k) {
+			return false
+		} else if !r.Contains(k) {
+			return true
+		}
+
+		if err = fn(k, strconv.S2B(value)); err != nil {
+			return false
+		}
+
+		n++
+
+		return !r.Full(n)
+	}
+
+	err2 := db.db.View(func(tx *buntdb.Tx) error {
+		switch {
+		case r.Reverse && len(r.End) > 0:
+			return tx.DescendLessOrEqual("", strconv.B2S(r.End), iter)
+		case r.Reverse:
+			return tx.Descend("", iter)
+		default:
+			return tx.AscendGreaterOrEqual("", strconv.B2S(r.Start), iter)
+		}
+	})
+
+	if err2 != nil {
+		return err2
+	}
+
+	return err
+}
+
+// Sync syncs the file of the store through another descriptor, since
+// buntdb writes every commit to the file but only syncs it by its policy.
+func (db *DB) Sync() error {
+	if db.path == store.MemoryPath {
+		return nil
+	}
+
+	f, err := os.Open(db.path)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	return f.Sync()
+}
+
+// Flush syncs the store, since buntdb appends every commit to its file.
 func (db *DB) Flush() error {
+	return db.Sync()
+}
+
+func (db *DB) Reset() error {
 	return db.db.Update(func(tx *buntdb.Tx) error {
 		return tx.DeleteAll()
 	})
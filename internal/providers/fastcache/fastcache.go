@@ -0,0 +1,163 @@
+package fastcache
+
+import (
+	"github.com/VictoriaMetrics/fastcache"
+	"github.com/savsgio/gotils/strconv"
+	"github.com/savsgio/kvbench/internal/common"
+	"github.com/savsgio/kvbench/internal/store"
+)
+
+// size is the capacity of the cache in bytes, beyond which it evicts
+// the oldest entries.
+const size = 128 << 20
+
+// DB is a fastcache, which keeps the entries in off-heap buckets of
+// chunks, but can't iterate them.
+type DB struct {
+	c *fastcache.Cache
+}
+
+func init() {
+	store.Register(store.Provider{
+		Name:     "fastcache",
+		Memory:   true,
+		Volatile: true,
+		Lacks:    store.CapIter | store.CapOrderedIter | store.CapRetention,
+		New:      New,
+	})
+}
+
+// New returns an empty cache, ignoring the path and the fsync flag.
+func New(path string, fsync bool) (store.DB, error) {
+	return &DB{c: fastcache.New(size)}, nil
+}
+
+// Set stores the entry, which fastcache drops silently if it doesn't fit
+// in a chunk of 64 KB.
+func (db *DB) Set(key, value []byte) error {
+	if len(key) == 0 {
+		return store.ErrEmptyKey
+	}
+
+	db.c.Set(key, value)
+
+	return nil
+}
+
+func (db *DB) SetString(key string, value []byte) error {
+	return db.Set(strconv.S2B(key), value)
+}
+
+func (db *DB) SetBulk(kvs ...common.KV) error {
+	for i := range kvs {
+		if len(kvs[i].Key) == 0 {
+			return store.ErrEmptyKey
+		}
+	}
+
+	for i := range kvs {
+		db.c.Set(kvs[i].Key, kvs[i].Value)
+	}
+
+	return nil
+}
+
+func (db *DB) Get(key []byte) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, store.ErrEmptyKey
+	}
+
+	v, ok := db.c.HasGet(nil, key)
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+
+	return v, nil
+}
+
+func (db *DB) GetString(key string) ([]byte, error) {
+	return db.Get(strconv.S2B(key))
+}
+
+// View calls fn with a copy of the value, since fastcache only copies it
+// out of its chunks.
+func (db *DB) View(key []byte, fn common.ViewFunc) error {
+	v, err := db.Get(key)
+	if err != nil {
+		return err
+	}
+
+	return fn(v)
+}
+
+func (db *DB) GetBulk(keys ...[]byte) ([]common.KV, error) {
+	kvs := make([]common.KV, len(keys))
+
+	for i := range keys {
+		key := keys[i]
+
+		if len(key) == 0 {
+			return nil, store.ErrEmptyKey
+		}
+
+		kv := &kvs[i]
+		kv.Key = append(kv.Key, key...)
+		kv.Value, kv.Found = db.c.HasGet(kv.Value, key)
+	}
+
+	return kvs, nil
+}
+
+func (db *DB) Del(key []byte) error {
+	if len(key) == 0 {
+		return store.ErrEmptyKey
+	}
+
+	db.c.Del(key)
+
+	return nil
+}
+
+func (db *DB) DelString(key string) error {
+	return db.Del(strconv.S2B(key))
+}
+
+func (db *DB) DelBulk(keys ...[]byte) error {
+	for i := range keys {
+		if len(keys[i]) == 0 {
+			return store.ErrEmptyKey
+		}
+	}
+
+	for i := range keys {
+		db.c.Del(keys[i])
+	}
+
+	return nil
+}
+
+func (db *DB) Iter(fn common.IterFunc) error {
+	return store.ErrUnsupported
+}
+
+func (db *DB) IterRange(r store.Range, fn common.IterFunc) error {
+	return store.ErrUnsupported
+}
+
+func (db *DB) Sync() error {
+	return nil
+}
+
+func (db *DB) Flush() error {
+	return nil
+}
+
+func (db *DB) Reset() error {
+	db.c.Reset()
+
+	return nil
+}
+
+func (db *DB) Close() error {
+	return db.Reset()
+}
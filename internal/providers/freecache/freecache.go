@@ -0,0 +1,189 @@
+package freecache
+
+import (
+	"errors"
+
+	"github.com/coocood/freecache"
+	"github.com/savsgio/gotils/strconv"
+	"github.com/savsgio/kvbench/internal/common"
+	"github.com/savsgio/kvbench/internal/store"
+)
+
+// size is the capacity of the cache in bytes, beyond which each segment
+// evicts its entries, and its 1/1024 is the maximum size of a value.
+const size = 128 << 20
+
+// DB is a freecache, which keeps the entries in ring buffers of segments
+// with their own lock, allocated up front.
+type DB struct {
+	c *freecache.Cache
+}
+
+func init() {
+	store.Register(store.Provider{
+		Name:     "freecache",
+		Memory:   true,
+		Volatile: true,
+		Lacks:    store.CapOrderedIter | store.CapRetention,
+		New:      New,
+	})
+}
+
+// New returns an empty cache, ignoring the path and the fsync flag.
+func New(path string, fsync bool) (store.DB, error) {
+	return &DB{c: freecache.NewCache(size)}, nil
+}
+
+func (db *DB) Set(key, value []byte) error {
+	if len(key) == 0 {
+		return store.ErrEmptyKey
+	}
+
+	// The entries never expire, they're only evicted by the size.
+	return db.c.Set(key, value, 0)
+}
+
+func (db *DB) SetString(key string, value []byte) error {
+	return db.Set(strconv.S2B(key), value)
+}
+
+func (db *DB) SetBulk(kvs ...common.KV) error {
+	for i := range kvs {
+		if len(kvs[i].Key) == 0 {
+			return store.ErrEmptyKey
+		}
+	}
+
+	for i := range kvs {
+		if err := db.c.Set(kvs[i].Key, kvs[i].Value, 0); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (db *DB) Get(key []byte) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, store.ErrEmptyKey
+	}
+
+	v, err := db.c.Get(key)
+	if errors.Is(err, freecache.ErrNotFound) {
+		return nil, store.ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+func (db *DB) GetString(key string) ([]byte, error) {
+	return db.Get(strconv.S2B(key))
+}
+
+// View calls fn with the value in the ring buffer, holding the lock of
+// its segment, so fn must not write to the store.
+func (db *DB) View(key []byte, fn common.ViewFunc) error {
+	if len(key) == 0 {
+		return store.ErrEmptyKey
+	}
+
+	err := db.c.GetFn(key, fn)
+	if errors.Is(err, freecache.ErrNotFound) {
+		return store.ErrNotFound
+	}
+
+	return err
+}
+
+func (db *DB) GetBulk(keys ...[]byte) ([]common.KV, error) {
+	kvs := make([]common.KV, len(keys))
+
+	for i := range keys {
+		key := keys[i]
+
+		if len(key) == 0 {
+			return nil, store.ErrEmptyKey
+		}
+
+		kv := &kvs[i]
+		kv.Key = append(kv.Key, key...)
+
+		v, err := db.c.Get(key)
+		if errors.Is(err, freecache.ErrNotFound) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		kv.Value = v
+		kv.Found = true
+	}
+
+	return kvs, nil
+}
+
+func (db *DB) Del(key []byte) error {
+	if len(key) == 0 {
+		return store.ErrEmptyKey
+	}
+
+	db.c.Del(key)
+
+	return nil
+}
+
+func (db *DB) DelString(key string) error {
+	return db.Del(strconv.S2B(key))
+}
+
+func (db *DB) DelBulk(keys ...[]byte) error {
+	for i := range keys {
+		if len(keys[i]) == 0 {
+			return store.ErrEmptyKey
+		}
+	}
+
+	for i := range keys {
+		db.c.Del(keys[i])
+	}
+
+	return nil
+}
+
+// Iter calls fn with copies of the entries in no order, locking each
+// segment only while copying an entry.
+func (db *DB) Iter(fn common.IterFunc) error {
+	it := db.c.NewIterator()
+
+	for e := it.Next(); e != nil; e = it.Next() {
+		if err := fn(e.Key, e.Value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (db *DB) IterRange(r store.Range, fn common.IterFunc) error {
+	return store.IterUnordered(r, db.Iter, fn)
+}
+
+func (db *DB) Sync() error {
+	return nil
+}
+
+func (db *DB) Flush() error {
+	return nil
+}
+
+func (db *DB) Reset() error {
+	db.c.Clear()
+
+	return nil
+}
+
+func (db *DB) Close() error {
+	return db.Reset()
+}
@@ -0,0 +1,213 @@
+package gomap
+
+import (
+	"sync"
+
+	"github.com/savsgio/gotils/strconv"
+	"github.com/savsgio/kvbench/internal/common"
+	"github.com/savsgio/kvbench/internal/store"
+)
+
+// DB is a Go map guarded by a sync.RWMutex, as the in-memory baseline
+// of the engines.
+type DB struct {
+	m  map[string][]byte
+	mu sync.RWMutex
+}
+
+func init() {
+	store.Register(store.Provider{
+		Name:     "map",
+		Memory:   true,
+		Volatile: true,
+		Lacks:    store.CapOrderedIter,
+		New:      New,
+	})
+}
+
+// New returns an empty store, ignoring the path and the fsync flag.
+func New(path string, fsync bool) (store.DB, error) {
+	return NewDB(), nil
+}
+
+// NewDB returns an empty store.
+func NewDB() *DB {
+	return &DB{
+		m: make(map[string][]byte),
+	}
+}
+
+// set stores a copy of the value, since the caller may reuse it.
+func (db *DB) set(key, value []byte) {
+	db.m[string(key)] = append([]byte{}, value...)
+}
+
+func (db *DB) Set(key, value []byte) error {
+	if len(key) == 0 {
+		return store.ErrEmptyKey
+	}
+
+	db.mu.Lock()
+	db.set(key, value)
+	db.mu.Unlock()
+
+	return nil
+}
+
+func (db *DB) SetString(key string, value []byte) error {
+	return db.Set(strconv.S2B(key), value)
+}
+
+func (db *DB) SetBulk(kvs ...common.KV) error {
+	for i := range kvs {
+		if len(kvs[i].Key) == 0 {
+			return store.ErrEmptyKey
+		}
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for i := range kvs {
+		db.set(kvs[i].Key, kvs[i].Value)
+	}
+
+	return nil
+}
+
+func (db *DB) Get(key []byte) ([]byte, error) {
+	var value []byte
+
+	err := db.View(key, func(v []byte) error {
+		value = append([]byte{}, v...)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+func (db *DB) GetString(key string) ([]byte, error) {
+	return db.Get(strconv.S2B(key))
+}
+
+// View calls fn with the stored value, which is never modified, but
+// replaced by the writes.
+func (db *DB) View(key []byte, fn common.ViewFunc) error {
+	if len(key) == 0 {
+		return store.ErrEmptyKey
+	}
+
+	db.mu.RLock()
+	v, ok := db.m[strconv.B2S(key)]
+	db.mu.RUnlock()
+
+	if !ok {
+		return store.ErrNotFound
+	}
+
+	return fn(v)
+}
+
+func (db *DB) GetBulk(keys ...[]byte) ([]common.KV, error) {
+	kvs := make([]common.KV, len(keys))
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	for i := range keys {
+		key := keys[i]
+
+		if len(key) == 0 {
+			return nil, store.ErrEmptyKey
+		}
+
+		kv := &kvs[i]
+		kv.Key = append(kv.Key, key...)
+
+		v, ok := db.m[strconv.B2S(key)]
+		if !ok {
+			continue
+		}
+
+		kv.Value = append(kv.Value, v...)
+		kv.Found = true
+	}
+
+	return kvs, nil
+}
+
+func (db *DB) Del(key []byte) error {
+	if len(key) == 0 {
+		return store.ErrEmptyKey
+	}
+
+	db.mu.Lock()
+	delete(db.m, strconv.B2S(key))
+	db.mu.Unlock()
+
+	return nil
+}
+
+func (db *DB) DelString(key string) error {
+	return db.Del(strconv.S2B(key))
+}
+
+func (db *DB) DelBulk(keys ...[]byte) error {
+	for i := range keys {
+		if len(keys[i]) == 0 {
+			return store.ErrEmptyKey
+		}
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for i := range keys {
+		delete(db.m, strconv.B2S(keys[i]))
+	}
+
+	return nil
+}
+
+// Iter calls fn with the entries in no order, holding the lock of the
+// store, so fn must not write to it.
+func (db *DB) Iter(fn common.IterFunc) error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	for k, v := range db.m {
+		if err := fn(strconv.S2B(k), v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (db *DB) IterRange(r store.Range, fn common.IterFunc) error {
+	return store.IterUnordered(r, db.Iter, fn)
+}
+
+func (db *DB) Sync() error {
+	return nil
+}
+
+func (db *DB) Flush() error {
+	return nil
+}
+
+func (db *DB) Reset() error {
+	db.mu.Lock()
+	db.m = make(map[string][]byte)
+	db.mu.Unlock()
+
+	return nil
+}
+
+func (db *DB) Close() error {
+	return db.Reset()
+}
@@ -3,6 +3,7 @@ package leveldb
 import (
 	"errors"
 	"os"
+	"path/filepath"
 	"sync"
 
 	"github.com/savsgio/gotils/strconv"
@@ -10,6 +11,8 @@ import (
 	"github.com/savsgio/kvbench/internal/store"
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 type DB struct {
@@ -21,6 +24,15 @@ type DB struct {
 	batchPool sync.Pool
 }
 
+func init() {
+	store.Register(store.Provider{
+		Name:   "leveldb",
+		Path:   "leveldb.db",
+		Memory: true,
+		New:    New,
+	})
+}
+
 func New(path string, fsync bool) (store.DB, error) {
 	db := &DB{
 		path:  path,
@@ -41,9 +53,22 @@ func New(path string, fsync bool) (store.DB, error) {
 }
 
 func (db *DB) init() error {
+	// Without fsync, goleveldb doesn't sync the manifest nor the tables
+	// either, so Sync syncs the files itself.
 	opts := &opt.Options{NoSync: !db.fsync}
 
-	ldb, err := leveldb.OpenFile(db.path, opts)
+	var (
+		ldb *leveldb.DB
+		err error
+	)
+
+	if db.memory() {
+		// Every init opens a new storage, so Reset drops the data.
+		ldb, err = leveldb.Open(storage.NewMemStorage(), opts)
+	} else {
+		ldb, err = leveldb.OpenFile(db.path, opts)
+	}
+
 	if err != nil {
 		return err
 	}
@@ -53,6 +78,10 @@ func (db *DB) init() error {
 	return nil
 }
 
+func (db *DB) memory() bool {
+	return db.path == store.MemoryPath
+}
+
 func (db *DB) acquireBatch() *leveldb.Batch {
 	return db.batchPool.Get().(*leveldb.Batch)
 }
@@ -63,6 +92,10 @@ func (db *DB) releaseBatch(batch *leveldb.Batch) {
 }
 
 func (db *DB) Set(key, value []byte) error {
+	if len(key) == 0 {
+		return store.ErrEmptyKey
+	}
+
 	db.mu.RLock()
 	defer db.mu.RUnlock()
 
@@ -83,6 +116,10 @@ func (db *DB) SetBulk(kvs ...common.KV) error {
 	for i := range kvs {
 		kv := kvs[i]
 
+		if len(kv.Key) == 0 {
+			return store.ErrEmptyKey
+		}
+
 		batch.Put(kv.Key, kv.Value)
 	}
 
@@ -90,11 +127,15 @@ func (db *DB) SetBulk(kvs ...common.KV) error {
 }
 
 func (db *DB) get(key []byte) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, store.ErrEmptyKey
+	}
+
 	value, err := db.db.Get(key, nil)
 
 	switch {
 	case err != nil && errors.Is(err, leveldb.ErrNotFound):
-		return nil, nil
+		return nil, store.ErrNotFound
 	case err != nil:
 		return nil, err
 	}
@@ -113,6 +154,19 @@ func (db *DB) GetString(key string) (val []byte, err error) {
 	return db.Get(strconv.S2B(key))
 }
 
+// View copies the value, since goleveldb always returns a copy.
+func (db *DB) View(key []byte, fn common.ViewFunc) error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	value, err := db.get(key)
+	if err != nil {
+		return err
+	}
+
+	return fn(value)
+}
+
 func (db *DB) GetBulk(keys ...[]byte) ([]common.KV, error) {
 	db.mu.RLock()
 	defer db.mu.RUnlock()
@@ -122,20 +176,30 @@ func (db *DB) GetBulk(keys ...[]byte) ([]common.KV, error) {
 	for i := range keys {
 		key := keys[i]
 
+		kv := &kvs[i]
+		kv.Key = append(kv.Key, key...)
+
 		value, err := db.get(key)
-		if err != nil {
+
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			continue
+		case err != nil:
 			return nil, err
 		}
 
-		kv := &kvs[i]
-		kv.Key = append(kv.Key, key...)
 		kv.Value = append(kv.Value, value...)
+		kv.Found = true
 	}
 
 	return kvs, nil
 }
 
 func (db *DB) del(key []byte) error {
+	if len(key) == 0 {
+		return store.ErrEmptyKey
+	}
+
 	ok, err := db.db.Has(key, nil)
 	if !ok || err != nil {
 		return err
@@ -168,7 +232,13 @@ func (db *DB) DelBulk(keys ...[]byte) error {
 	defer db.releaseBatch(batch)
 
 	for i := range keys {
-		batch.Delete(keys[i])
+		key := keys[i]
+
+		if len(key) == 0 {
+			return store.ErrEmptyKey
+		}
+
+		batch.Delete(key)
 	}
 
 	return db.db.Write(batch, &db.wo)
@@ -181,7 +251,37 @@ func (db *DB) Iter(fn common.IterFunc) error {
 	it := db.db.NewIterator(nil, nil)
 	defer it.Release()
 
-	for it.First(); it.Next(); {
+	for ok := it.First(); ok; ok = it.Next() {
+		if err := fn(it.Key(), it.Value()); err != nil {
+			return err
+		}
+	}
+
+	return it.Error()
+}
+
+func (db *DB) IterRange(r store.Range, fn common.IterFunc) error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	slice := new(util.Range)
+	if len(r.Start) > 0 {
+		slice.Start = r.Start
+	}
+
+	if len(r.End) > 0 {
+		slice.Limit = r.End
+	}
+
+	it := db.db.NewIterator(slice, nil)
+	defer it.Release()
+
+	first, next := it.First, it.Next
+	if r.Reverse {
+		first, next = it.Last, it.Prev
+	}
+
+	for ok, n := first(), 0; ok && !r.Full(n); ok, n = next(), n+1 {
 		if err := fn(it.Key(), it.Value()); err != nil {
 			return err
 		}
@@ -190,7 +290,76 @@ func (db *DB) Iter(fn common.IterFunc) error {
 	return it.Error()
 }
 
+// Sync writes a deletion of the empty key, which can't be set, as
+// goleveldb skips the empty batches and has no other way to sync the
+// journal. Without fsync, the write only flushes the journal to its file,
+// so the journal and the manifest are synced through other descriptors,
+// like the write-ahead logs of the other engines. The tables written by
+// the compactions aren't.
+func (db *DB) Sync() error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	batch := db.acquireBatch()
+	defer db.releaseBatch(batch)
+
+	batch.Delete(nil)
+
+	if err := db.db.Write(batch, &opt.WriteOptions{Sync: true}); err != nil {
+		return err
+	}
+
+	if db.fsync || db.memory() {
+		return nil
+	}
+
+	return syncLogs(db.path)
+}
+
+// syncLogs syncs the journal and the manifest files in dir.
+func syncLogs(dir string) error {
+	for _, pattern := range []string{"*.log", "MANIFEST-*"} {
+		paths, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return err
+		}
+
+		for _, path := range paths {
+			if err := syncFile(path); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func syncFile(path string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		// Replaced since the directory was read.
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	err = f.Sync()
+	f.Close()
+
+	return err
+}
+
+// Flush compacts the whole key space, as goleveldb can't flush the
+// memtable alone, so it costs far more than the flushes of the other
+// engines.
 func (db *DB) Flush() error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	return db.db.CompactRange(util.Range{})
+}
+
+func (db *DB) Reset() error {
 	db.mu.Lock()
 	defer db.mu.Unlock()
 
@@ -198,7 +367,9 @@ func (db *DB) Flush() error {
 		return err
 	}
 
-	os.RemoveAll(db.path)
+	if !db.memory() {
+		os.RemoveAll(db.path)
+	}
 
 	return db.init()
 }
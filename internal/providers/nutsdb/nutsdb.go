@@ -1,7 +1,9 @@
 package nutsdb
 
 import (
+	"bytes"
 	"errors"
+	"os"
 	"sync"
 
 	"github.com/savsgio/gotils/strconv"
@@ -15,6 +17,10 @@ const (
 	keyInit = "init"
 )
 
+// maxKey is the end of the ranges without end, greater than any key of
+// the benchmarks.
+var maxKey = bytes.Repeat([]byte{0xff}, 1024)
+
 type DB struct {
 	path  string
 	fsync bool
@@ -22,6 +28,15 @@ type DB struct {
 	mu    sync.RWMutex
 }
 
+func init() {
+	store.Register(store.Provider{
+		Name:  "nutsdb",
+		Path:  "nutsdb.db",
+		Lacks: store.CapOrderedIter,
+		New:   New,
+	})
+}
+
 func New(path string, fsync bool) (store.DB, error) {
 	db := &DB{
 		path:  path,
@@ -48,26 +63,41 @@ func (db *DB) init() error {
 
 	db.db = ndb
 
-	if err := db.SetString(keyInit, nil); err != nil {
+	// Creates the bucket, which the reads of a new store would fail
+	// without.
+	if err := db.set(strconv.S2B(keyInit), []byte{}); err != nil {
 		return store.ErrInit
 	}
 
-	if err := db.DelString(keyInit); err != nil {
+	if err := db.del(strconv.S2B(keyInit)); err != nil {
 		return store.ErrInit
 	}
 
 	return nil
 }
 
-func (db *DB) Set(key, value []byte) error {
-	db.mu.RLock()
-	defer db.mu.RUnlock()
+// isNotFound reports whether err means that the key doesn't exist.
+func isNotFound(err error) bool {
+	return errors.Is(err, nutsdb.ErrKeyNotFound) || errors.Is(err, nutsdb.ErrNotFoundKey)
+}
 
+func (db *DB) set(key, value []byte) error {
 	return db.db.Update(func(tx *nutsdb.Tx) error {
 		return tx.Put(bucket, key, value, 0)
 	})
 }
 
+func (db *DB) Set(key, value []byte) error {
+	if len(key) == 0 {
+		return store.ErrEmptyKey
+	}
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	return db.set(key, value)
+}
+
 func (db *DB) SetString(key string, value []byte) error {
 	return db.Set(strconv.S2B(key), value)
 }
@@ -80,6 +110,10 @@ func (db *DB) SetBulk(kvs ...common.KV) error {
 		for i := range kvs {
 			kv := kvs[i]
 
+			if len(kv.Key) == 0 {
+				return store.ErrEmptyKey
+			}
+
 			if err := tx.Put(bucket, kv.Key, kv.Value, 0); err != nil {
 				return err
 			}
@@ -90,6 +124,10 @@ func (db *DB) SetBulk(kvs ...common.KV) error {
 }
 
 func (db *DB) Get(key []byte) (value []byte, err error) {
+	if len(key) == 0 {
+		return nil, store.ErrEmptyKey
+	}
+
 	db.mu.RLock()
 	defer db.mu.RUnlock()
 
@@ -97,8 +135,8 @@ func (db *DB) Get(key []byte) (value []byte, err error) {
 		e, err := tx.Get(bucket, key)
 
 		switch {
-		case err != nil && errors.Is(err, nutsdb.ErrKeyNotFound), errors.Is(err, nutsdb.ErrNotFoundKey):
-			return nil
+		case err != nil && isNotFound(err):
+			return store.ErrNotFound
 		case err != nil:
 			return err
 		}
@@ -115,6 +153,28 @@ func (db *DB) GetString(key string) ([]byte, error) {
 	return db.Get(strconv.S2B(key))
 }
 
+func (db *DB) View(key []byte, fn common.ViewFunc) error {
+	if len(key) == 0 {
+		return store.ErrEmptyKey
+	}
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	return db.db.View(func(tx *nutsdb.Tx) error {
+		e, err := tx.Get(bucket, key)
+
+		switch {
+		case err != nil && isNotFound(err):
+			return store.ErrNotFound
+		case err != nil:
+			return err
+		}
+
+		return fn(e.Value)
+	})
+}
+
 func (db *DB) GetBulk(keys ...[]byte) ([]common.KV, error) {
 	db.mu.RLock()
 	defer db.mu.RUnlock()
@@ -125,14 +185,24 @@ func (db *DB) GetBulk(keys ...[]byte) ([]common.KV, error) {
 		for i := range keys {
 			key := keys[i]
 
-			e, err := tx.Get(bucket, key)
-			if err != nil && !(errors.Is(err, nutsdb.ErrKeyNotFound) || errors.Is(err, nutsdb.ErrNotFoundKey)) {
-				return err
+			if len(key) == 0 {
+				return store.ErrEmptyKey
 			}
 
 			kv := &kvs[i]
 			kv.Key = append(kv.Key, key...)
+
+			e, err := tx.Get(bucket, key)
+
+			switch {
+			case err != nil && isNotFound(err):
+				continue
+			case err != nil:
+				return err
+			}
+
 			kv.Value = append(kv.Value, e.Value...)
+			kv.Found = true
 		}
 
 		return nil
@@ -145,13 +215,21 @@ func (db *DB) GetBulk(keys ...[]byte) ([]common.KV, error) {
 	return kvs, nil
 }
 
+func (db *DB) del(key []byte) error {
+	return db.db.Update(func(tx *nutsdb.Tx) error {
+		return tx.Delete(bucket, key)
+	})
+}
+
 func (db *DB) Del(key []byte) error {
+	if len(key) == 0 {
+		return store.ErrEmptyKey
+	}
+
 	db.mu.RLock()
 	defer db.mu.RUnlock()
 
-	return db.db.Update(func(tx *nutsdb.Tx) error {
-		return tx.Delete(bucket, key)
-	})
+	return db.del(key)
 }
 
 func (db *DB) DelString(key string) error {
@@ -166,6 +244,10 @@ func (db *DB) DelBulk(keys ...[]byte) error {
 		for i := range keys {
 			key := keys[i]
 
+			if len(key) == 0 {
+				return store.ErrEmptyKey
+			}
+
 			if err := tx.Delete(bucket, key); err != nil {
 				return err
 			}
@@ -183,26 +265,100 @@ func (db *DB) Iter(fn common.IterFunc) error {
 		entries, err := tx.GetAll(bucket)
 
 		switch {
-		case err != nil && errors.Is(err, nutsdb.ErrBucketEmpty):
+		case err != nil && (errors.Is(err, nutsdb.ErrBucketEmpty) || errors.Is(err, nutsdb.ErrRangeScan)):
+			return nil
+		case err != nil:
+			return err
+		}
+
+		for i := range entries {
+			entry := entries[i]
+
+			if err := fn(entry.Key, entry.Value); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// IterRange scans the range at once, since nutsdb doesn't provide
+// cursors, so the limit doesn't bound the cost of the scan, which is why
+// the store lacks CapOrderedIter.
+func (db *DB) IterRange(r store.Range, fn common.IterFunc) error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	end := r.End
+	if len(end) == 0 {
+		end = maxKey
+	}
+
+	return db.db.View(func(tx *nutsdb.Tx) error {
+		entries, err := tx.RangeScan(bucket, r.Start, end)
+
+		switch {
+		case err != nil && errors.Is(err, nutsdb.ErrRangeScan):
 			return nil
 		case err != nil:
 			return err
 		}
 
+		n := 0
+
 		for i := range entries {
 			entry := entries[i]
+			if r.Reverse {
+				entry = entries[len(entries)-1-i]
+			}
+
+			// The end of the range scan is inclusive.
+			if !r.Contains(entry.Key) {
+				continue
+			} else if r.Full(n) {
+				break
+			}
 
 			if err := fn(entry.Key, entry.Value); err != nil {
 				return err
 			}
+
+			n++
 		}
 
 		return nil
 	})
 }
 
+// Sync syncs the active data file within a transaction, which holds the
+// lock of nutsdb, so the writes can't rotate the file meanwhile.
+func (db *DB) Sync() error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	return db.db.View(func(tx *nutsdb.Tx) error {
+		return db.db.ActiveFile.Sync()
+	})
+}
+
+// Flush syncs the store, since nutsdb writes the entries straight to its
+// data files.
 func (db *DB) Flush() error {
-	return db.db.ActiveFile.Sync()
+	return db.Sync()
+}
+
+func (db *DB) Reset() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if err := db.close(); err != nil {
+		return err
+	}
+
+	os.RemoveAll(db.path)
+
+	return db.init()
 }
 
 func (db *DB) close() error {
@@ -4,6 +4,7 @@ import (
 	"errors"
 
 	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/vfs"
 	"github.com/savsgio/gotils/strconv"
 	"github.com/savsgio/kvbench/internal/common"
 	"github.com/savsgio/kvbench/internal/store"
@@ -16,6 +17,15 @@ type DB struct {
 	wo    *pebble.WriteOptions
 }
 
+func init() {
+	store.Register(store.Provider{
+		Name:   "pebble",
+		Path:   "pebble.db",
+		Memory: true,
+		New:    New,
+	})
+}
+
 func New(path string, fsync bool) (store.DB, error) {
 	db := &DB{
 		path:  path,
@@ -39,6 +49,12 @@ func (db *DB) init() error {
 		Sync: db.fsync,
 	}
 
+	// The path is a directory of the in-memory filesystem then, where
+	// the syncs are no-ops.
+	if db.path == store.MemoryPath {
+		opts.FS = vfs.NewMem()
+	}
+
 	pdb, err := pebble.Open(db.path, opts)
 	if err != nil {
 		return err
@@ -85,38 +101,64 @@ func (db *DB) Get(key []byte) ([]byte, error) {
 		return nil, store.ErrEmptyKey
 	}
 
-	v, closer, err := db.db.Get(key)
+	var value []byte
 
-	switch {
-	case err != nil && errors.Is(err, pebble.ErrNotFound):
-		return nil, nil
-	case err != nil:
+	err := db.View(key, func(v []byte) error {
+		// The value is only valid until the closer is closed.
+		value = append([]byte{}, v...)
+
+		return nil
+	})
+	if err != nil {
 		return nil, err
-	default:
-		closer.Close()
 	}
 
-	return v, nil
+	return value, nil
 }
 
 func (db *DB) GetString(key string) (val []byte, err error) {
 	return db.Get(strconv.S2B(key))
 }
 
+func (db *DB) View(key []byte, fn common.ViewFunc) error {
+	if len(key) == 0 {
+		return store.ErrEmptyKey
+	}
+
+	v, closer, err := db.db.Get(key)
+
+	switch {
+	case err != nil && errors.Is(err, pebble.ErrNotFound):
+		return store.ErrNotFound
+	case err != nil:
+		return err
+	}
+
+	defer closer.Close()
+
+	return fn(v)
+}
+
 func (db *DB) GetBulk(keys ...[]byte) ([]common.KV, error) {
 	kvs := make([]common.KV, len(keys))
 
 	for i := range keys {
 		key := keys[i]
 
+		kv := &kvs[i]
+		kv.Key = append(kv.Key, key...)
+
 		value, err := db.Get(key)
-		if err != nil {
+
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			continue
+		case err != nil:
 			return nil, err
 		}
 
-		kv := &kvs[i]
-		kv.Key = append(kv.Key, key...)
 		kv.Value = append(kv.Value, value...)
+		kv.Found = true
 	}
 
 	return kvs, nil
@@ -127,7 +169,7 @@ func (db *DB) Del(key []byte) error {
 		return store.ErrEmptyKey
 	}
 
-	return db.db.SingleDelete(key, db.wo)
+	return db.db.Delete(key, db.wo)
 }
 
 func (db *DB) DelString(key string) error {
@@ -160,21 +202,71 @@ func (db *DB) Iter(fn common.IterFunc) error {
 	it := snapshot.NewIter(&pebble.IterOptions{})
 	defer it.Close()
 
-	it.First()
+	for ok := it.First(); ok; ok = it.Next() {
+		if err := fn(it.Key(), it.Value()); err != nil {
+			return err
+		}
+	}
+
+	return it.Error()
+}
 
-	for it.Next() {
+func (db *DB) IterRange(r store.Range, fn common.IterFunc) error {
+	opts := &pebble.IterOptions{}
+	if len(r.Start) > 0 {
+		opts.LowerBound = r.Start
+	}
+
+	if len(r.End) > 0 {
+		opts.UpperBound = r.End
+	}
+
+	it := db.db.NewIter(opts)
+	defer it.Close()
+
+	first, next := it.First, it.Next
+	if r.Reverse {
+		first, next = it.Last, it.Prev
+	}
+
+	for ok, n := first(), 0; ok && !r.Full(n); ok, n = next(), n+1 {
 		if err := fn(it.Key(), it.Value()); err != nil {
 			return err
 		}
 	}
 
-	return nil
+	return it.Error()
+}
+
+// Sync logs an empty record with sync, which syncs the WAL.
+func (db *DB) Sync() error {
+	return db.db.LogData(nil, pebble.Sync)
 }
 
 func (db *DB) Flush() error {
 	return db.db.Flush()
 }
 
+// Reset deletes the range from the first key to the last one.
+func (db *DB) Reset() error {
+	it := db.db.NewIter(nil)
+
+	if !it.First() {
+		return it.Close()
+	}
+
+	start := append([]byte(nil), it.Key()...)
+
+	it.Last()
+	end := append(append([]byte(nil), it.Key()...), 0)
+
+	if err := it.Close(); err != nil {
+		return err
+	}
+
+	return db.db.DeleteRange(start, end, db.wo)
+}
+
 func (db *DB) Close() error {
 	return db.db.Close()
 }
@@ -18,6 +18,15 @@ type DB struct {
 	mu    sync.RWMutex
 }
 
+func init() {
+	store.Register(store.Provider{
+		Name:  "pogreb",
+		Path:  "pogreb.db",
+		Lacks: store.CapOrderedIter,
+		New:   New,
+	})
+}
+
 func New(path string, fsync bool) (store.DB, error) {
 	db := &DB{
 		path:  path,
@@ -32,7 +41,7 @@ func New(path string, fsync bool) (store.DB, error) {
 }
 
 func (db *DB) init() error {
-	if db.path == ":memory:" {
+	if db.path == store.MemoryPath {
 		return store.ErrMemoryNotAllowed
 	}
 
@@ -52,6 +61,10 @@ func (db *DB) init() error {
 }
 
 func (db *DB) set(key, value []byte) error {
+	if len(key) == 0 {
+		return store.ErrEmptyKey
+	}
+
 	return db.db.Put(key, value)
 }
 
@@ -82,7 +95,22 @@ func (db *DB) SetBulk(kvs ...common.KV) error {
 }
 
 func (db *DB) get(key []byte) ([]byte, error) {
-	return db.db.Get(key)
+	if len(key) == 0 {
+		return nil, store.ErrEmptyKey
+	}
+
+	// pogreb returns a nil value only for a missing key, since it copies
+	// the found ones.
+	value, err := db.db.Get(key)
+
+	switch {
+	case err != nil:
+		return nil, err
+	case value == nil:
+		return nil, store.ErrNotFound
+	}
+
+	return value, nil
 }
 
 func (db *DB) Get(key []byte) ([]byte, error) {
@@ -96,6 +124,19 @@ func (db *DB) GetString(key string) (val []byte, err error) {
 	return db.Get(strconv.S2B(key))
 }
 
+// View copies the value, since pogreb always returns a copy.
+func (db *DB) View(key []byte, fn common.ViewFunc) error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	value, err := db.get(key)
+	if err != nil {
+		return err
+	}
+
+	return fn(value)
+}
+
 func (db *DB) GetBulk(keys ...[]byte) ([]common.KV, error) {
 	db.mu.RLock()
 	defer db.mu.RUnlock()
@@ -105,20 +146,30 @@ func (db *DB) GetBulk(keys ...[]byte) ([]common.KV, error) {
 	for i := range keys {
 		key := keys[i]
 
+		kv := &kvs[i]
+		kv.Key = append(kv.Key, key...)
+
 		value, err := db.get(key)
-		if err != nil {
+
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			continue
+		case err != nil:
 			return nil, err
 		}
 
-		kv := &kvs[i]
-		kv.Key = append(kv.Key, key...)
 		kv.Value = append(kv.Value, value...)
+		kv.Found = true
 	}
 
 	return kvs, nil
 }
 
 func (db *DB) del(key []byte) error {
+	if len(key) == 0 {
+		return store.ErrEmptyKey
+	}
+
 	return db.db.Delete(key)
 }
 
@@ -165,7 +216,26 @@ func (db *DB) Iter(fn common.IterFunc) error {
 	}
 }
 
+// IterRange sorts the entries of the range, since pogreb iterates them
+// in hash order.
+func (db *DB) IterRange(r store.Range, fn common.IterFunc) error {
+	return store.IterUnordered(r, db.Iter, fn)
+}
+
+func (db *DB) Sync() error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	return db.db.Sync()
+}
+
+// Flush syncs the store, since pogreb writes the records straight to its
+// segment files.
 func (db *DB) Flush() error {
+	return db.Sync()
+}
+
+func (db *DB) Reset() error {
 	db.mu.Lock()
 	defer db.mu.Unlock()
 
@@ -0,0 +1,23 @@
+// Package providers registers all the store engines.
+//
+// Import it for its side effects to make every engine available
+// through the store registry.
+package providers
+
+import (
+	_ "github.com/savsgio/kvbench/internal/providers/badger"
+	_ "github.com/savsgio/kvbench/internal/providers/bbolt"
+	_ "github.com/savsgio/kvbench/internal/providers/bigcache"
+	_ "github.com/savsgio/kvbench/internal/providers/buntdb"
+	_ "github.com/savsgio/kvbench/internal/providers/fastcache"
+	_ "github.com/savsgio/kvbench/internal/providers/freecache"
+	_ "github.com/savsgio/kvbench/internal/providers/gomap"
+	_ "github.com/savsgio/kvbench/internal/providers/leveldb"
+	_ "github.com/savsgio/kvbench/internal/providers/nutsdb"
+	_ "github.com/savsgio/kvbench/internal/providers/pebble"
+	_ "github.com/savsgio/kvbench/internal/providers/pogreb"
+	_ "github.com/savsgio/kvbench/internal/providers/ristretto"
+	_ "github.com/savsgio/kvbench/internal/providers/shardedmap"
+	_ "github.com/savsgio/kvbench/internal/providers/sqlite"
+	_ "github.com/savsgio/kvbench/internal/providers/syncmap"
+)
@@ -1,94 +1,94 @@
 package providers
 
 import (
-	"encoding/binary"
 	"flag"
-	"os"
+	"path/filepath"
 	"testing"
 
-	"github.com/savsgio/kvbench/internal/providers/badger"
-	"github.com/savsgio/kvbench/internal/providers/buntdb"
-	"github.com/savsgio/kvbench/internal/providers/leveldb"
-	"github.com/savsgio/kvbench/internal/providers/nutsdb"
-	"github.com/savsgio/kvbench/internal/providers/pebble"
-	"github.com/savsgio/kvbench/internal/providers/pogreb"
 	"github.com/savsgio/kvbench/internal/store"
+	"github.com/savsgio/kvbench/internal/store/storetest"
 )
 
 var count = flag.Int("count", 1000, "item count for test")
 
-var stores = []struct {
-	Name    string
-	Path    string
-	Factory func(path string, fsync bool) (store.DB, error)
-}{
-	{"badger", "badger.db", badger.New},
-	{"leveldb", "leveldb.db", leveldb.New},
-	{"buntdb", "buntdb.db", buntdb.New},
-	{"pebble", "pebble.db", pebble.New},
-	{"pogreb", "pogreb.db", pogreb.New},
-	{"nutsdb", "nutsdb.db", nutsdb.New},
-}
-
-func prefixKey(i int) []byte {
-	r := make([]byte, 8)
-	binary.BigEndian.PutUint64(r, uint64(i))
-
-	return r
-}
-
-func wrapfsync(fn func(*testing.T, store.DB, bool), s store.DB, fsync bool) func(*testing.T) {
-	return func(t *testing.T) {
-		fn(t, s, fsync)
-	}
-}
 func TestStore_fsync(t *testing.T) {
-	for _, s := range stores {
-		store, err := s.Factory(s.Path, true)
-		if err != nil {
-			os.RemoveAll(s.Path)
-			t.Fatal(err)
-		}
-		t.Run(s.Name, wrapfsync(testStore, store, true))
-		os.RemoveAll(s.Path)
+	for _, p := range store.Providers() {
+		p := p
+
+		t.Run(p.Name, func(t *testing.T) {
+			storetest.Run(t, p, true, *count)
+		})
 	}
 }
 
 func TestStore_nofsync(t *testing.T) {
-	for _, s := range stores {
-		store, err := s.Factory(s.Path, false)
-		if err != nil {
-			os.RemoveAll(s.Path)
-			t.Fatal(err)
-		}
-		t.Run(s.Name, wrapfsync(testStore, store, false))
-		os.RemoveAll(s.Path)
+	for _, p := range store.Providers() {
+		p := p
+
+		t.Run(p.Name, func(t *testing.T) {
+			storetest.Run(t, p, false, *count)
+		})
 	}
 }
 
-func testStore(t *testing.T, s store.DB, fsync bool) {
-	v := make([]byte, 256)
+func TestStore_memory(t *testing.T) {
+	for _, p := range store.Providers() {
+		p := p
+
+		t.Run(p.Name, func(t *testing.T) {
+			storetest.RunMemory(t, p, *count)
+		})
+	}
+}
 
-	defer s.Close()
+// benchmarkRead measures the reads of the loaded keys of every provider.
+func benchmarkRead(b *testing.B, read func(db store.DB, key []byte) error) {
+	for _, p := range store.Providers() {
+		p := p
 
-	t.Run("set", func(tt *testing.T) {
-		for i := 0; i < *count; i++ {
-			err := s.Set(prefixKey(i), v)
+		b.Run(p.Name, func(b *testing.B) {
+			db, _, err := p.Open(filepath.Join(b.TempDir(), p.Path), false)
 			if err != nil {
-				tt.Fatalf("failed to set key %d: %v", i, err)
+				b.Fatal(err)
 			}
-		}
-	})
 
-	t.Run("get", func(tt *testing.T) {
-		for i := 0; i < *count; i++ {
-			value, err := s.Get(prefixKey(i))
-			if err != nil {
-				tt.Fatalf("failed to get key %d: %v", i, err)
+			defer db.Close()
+
+			for i := 0; i < *count; i++ {
+				if err := db.Set(storetest.Key(i), storetest.Value(i, 0)); err != nil {
+					b.Fatal(err)
+				}
 			}
-			if len(value) == 0 {
-				tt.Fatalf("the key %d does not exist", i)
+
+			// The caches may apply the writes asynchronously.
+			if err := db.Sync(); err != nil {
+				b.Fatal(err)
 			}
-		}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				if err := read(db, storetest.Key(i%*count)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkGet(b *testing.B) {
+	benchmarkRead(b, func(db store.DB, key []byte) error {
+		_, err := db.Get(key)
+
+		return err
+	})
+}
+
+func BenchmarkView(b *testing.B) {
+	benchmarkRead(b, func(db store.DB, key []byte) error {
+		return db.View(key, func(value []byte) error {
+			return nil
+		})
 	})
 }
@@ -0,0 +1,204 @@
+package ristretto
+
+import (
+	"github.com/dgraph-io/ristretto"
+	"github.com/savsgio/gotils/strconv"
+	"github.com/savsgio/kvbench/internal/common"
+	"github.com/savsgio/kvbench/internal/store"
+)
+
+const (
+	// size is the capacity of the cache, as the cost of its values in bytes.
+	size = 128 << 20
+
+	// counters is the number of admission counters, ten times the number
+	// of the entries expected when the cache is full.
+	counters = 10_000_000
+)
+
+// DB is a ristretto cache, which only keeps the hashes of the keys, so it
+// can't iterate them, and applies the writes asynchronously.
+type DB struct {
+	c *ristretto.Cache
+}
+
+func init() {
+	store.Register(store.Provider{
+		Name:     "ristretto",
+		Memory:   true,
+		Volatile: true,
+		Lacks:    store.CapIter | store.CapOrderedIter | store.CapRetention | store.CapAdmitAll,
+		New:      New,
+	})
+}
+
+// New returns an empty cache, ignoring the path and the fsync flag.
+func New(path string, fsync bool) (store.DB, error) {
+	c, err := ristretto.NewCache(&ristretto.Config{
+		NumCounters: counters,
+		MaxCost:     size,
+		BufferItems: 64,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &DB{c: c}, nil
+}
+
+// set stores a copy of the value, since the caller may reuse it.
+//
+// The write is visible once it's applied, unless ristretto drops it when
+// its buffer is full, or rejects it by its admission policy.
+func (db *DB) set(key, value []byte) {
+	v := append([]byte{}, value...)
+
+	db.c.Set(key, v, int64(len(v)))
+}
+
+func (db *DB) Set(key, value []byte) error {
+	if len(key) == 0 {
+		return store.ErrEmptyKey
+	}
+
+	db.set(key, value)
+
+	return nil
+}
+
+func (db *DB) SetString(key string, value []byte) error {
+	return db.Set(strconv.S2B(key), value)
+}
+
+func (db *DB) SetBulk(kvs ...common.KV) error {
+	for i := range kvs {
+		if len(kvs[i].Key) == 0 {
+			return store.ErrEmptyKey
+		}
+	}
+
+	for i := range kvs {
+		db.set(kvs[i].Key, kvs[i].Value)
+	}
+
+	return nil
+}
+
+func (db *DB) Get(key []byte) ([]byte, error) {
+	var value []byte
+
+	err := db.View(key, func(v []byte) error {
+		value = append([]byte{}, v...)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+func (db *DB) GetString(key string) ([]byte, error) {
+	return db.Get(strconv.S2B(key))
+}
+
+// View calls fn with the stored value, which is never modified, but
+// replaced by the writes.
+func (db *DB) View(key []byte, fn common.ViewFunc) error {
+	if len(key) == 0 {
+		return store.ErrEmptyKey
+	}
+
+	v, ok := db.c.Get(key)
+	if !ok {
+		return store.ErrNotFound
+	}
+
+	return fn(v.([]byte))
+}
+
+func (db *DB) GetBulk(keys ...[]byte) ([]common.KV, error) {
+	kvs := make([]common.KV, len(keys))
+
+	for i := range keys {
+		key := keys[i]
+
+		if len(key) == 0 {
+			return nil, store.ErrEmptyKey
+		}
+
+		kv := &kvs[i]
+		kv.Key = append(kv.Key, key...)
+
+		v, ok := db.c.Get(key)
+		if !ok {
+			continue
+		}
+
+		kv.Value = append(kv.Value, v.([]byte)...)
+		kv.Found = true
+	}
+
+	return kvs, nil
+}
+
+func (db *DB) Del(key []byte) error {
+	if len(key) == 0 {
+		return store.ErrEmptyKey
+	}
+
+	db.c.Del(key)
+
+	return nil
+}
+
+func (db *DB) DelString(key string) error {
+	return db.Del(strconv.S2B(key))
+}
+
+func (db *DB) DelBulk(keys ...[]byte) error {
+	for i := range keys {
+		if len(keys[i]) == 0 {
+			return store.ErrEmptyKey
+		}
+	}
+
+	for i := range keys {
+		db.c.Del(keys[i])
+	}
+
+	return nil
+}
+
+func (db *DB) Iter(fn common.IterFunc) error {
+	return store.ErrUnsupported
+}
+
+func (db *DB) IterRange(r store.Range, fn common.IterFunc) error {
+	return store.ErrUnsupported
+}
+
+// Sync waits for the buffered writes to be applied.
+func (db *DB) Sync() error {
+	db.c.Wait()
+
+	return nil
+}
+
+func (db *DB) Flush() error {
+	return db.Sync()
+}
+
+// Reset clears the cache, dropping the buffered writes too.
+func (db *DB) Reset() error {
+	db.c.Clear()
+
+	return nil
+}
+
+func (db *DB) Close() error {
+	db.c.Close()
+
+	return nil
+}
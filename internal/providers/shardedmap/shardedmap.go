@@ -0,0 +1,163 @@
+package shardedmap
+
+import (
+	"github.com/savsgio/gotils/strconv"
+	"github.com/savsgio/kvbench/internal/common"
+	"github.com/savsgio/kvbench/internal/providers/gomap"
+	"github.com/savsgio/kvbench/internal/store"
+)
+
+// shards is the number of maps, a power of two.
+const shards = 64
+
+// DB stripes the keys among maps with their own lock, so the writes to
+// different shards don't wait for each other.
+type DB struct {
+	shards [shards]*gomap.DB
+}
+
+func init() {
+	store.Register(store.Provider{
+		Name:     "shardedmap",
+		Memory:   true,
+		Volatile: true,
+		Lacks:    store.CapOrderedIter,
+		New:      New,
+	})
+}
+
+// New returns an empty store, ignoring the path and the fsync flag.
+func New(path string, fsync bool) (store.DB, error) {
+	db := &DB{}
+
+	for i := range db.shards {
+		db.shards[i] = gomap.NewDB()
+	}
+
+	return db, nil
+}
+
+// shard returns the map of the key, chosen by its FNV-1a hash.
+func (db *DB) shard(key []byte) *gomap.DB {
+	h := uint32(2166136261)
+	for _, b := range key {
+		h ^= uint32(b)
+		h *= 16777619
+	}
+
+	return db.shards[h&(shards-1)]
+}
+
+func (db *DB) Set(key, value []byte) error {
+	return db.shard(key).Set(key, value)
+}
+
+func (db *DB) SetString(key string, value []byte) error {
+	return db.Set(strconv.S2B(key), value)
+}
+
+// SetBulk sets the entries shard by shard, so the bulk isn't atomic.
+func (db *DB) SetBulk(kvs ...common.KV) error {
+	for i := range kvs {
+		if len(kvs[i].Key) == 0 {
+			return store.ErrEmptyKey
+		}
+	}
+
+	for i := range kvs {
+		if err := db.Set(kvs[i].Key, kvs[i].Value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (db *DB) Get(key []byte) ([]byte, error) {
+	return db.shard(key).Get(key)
+}
+
+func (db *DB) GetString(key string) ([]byte, error) {
+	return db.Get(strconv.S2B(key))
+}
+
+func (db *DB) View(key []byte, fn common.ViewFunc) error {
+	return db.shard(key).View(key, fn)
+}
+
+func (db *DB) GetBulk(keys ...[]byte) ([]common.KV, error) {
+	kvs := make([]common.KV, len(keys))
+
+	for i := range keys {
+		res, err := db.shard(keys[i]).GetBulk(keys[i])
+		if err != nil {
+			return nil, err
+		}
+
+		kvs[i] = res[0]
+	}
+
+	return kvs, nil
+}
+
+func (db *DB) Del(key []byte) error {
+	return db.shard(key).Del(key)
+}
+
+func (db *DB) DelString(key string) error {
+	return db.Del(strconv.S2B(key))
+}
+
+func (db *DB) DelBulk(keys ...[]byte) error {
+	for i := range keys {
+		if len(keys[i]) == 0 {
+			return store.ErrEmptyKey
+		}
+	}
+
+	for i := range keys {
+		if err := db.Del(keys[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Iter calls fn with the entries of each shard in turn, holding its lock,
+// so fn must not write to the store.
+func (db *DB) Iter(fn common.IterFunc) error {
+	for _, s := range db.shards {
+		if err := s.Iter(fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (db *DB) IterRange(r store.Range, fn common.IterFunc) error {
+	return store.IterUnordered(r, db.Iter, fn)
+}
+
+func (db *DB) Sync() error {
+	return nil
+}
+
+func (db *DB) Flush() error {
+	return nil
+}
+
+func (db *DB) Reset() error {
+	for _, s := range db.shards {
+		if err := s.Reset(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (db *DB) Close() error {
+	return db.Reset()
+}
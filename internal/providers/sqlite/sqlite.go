@@ -0,0 +1,528 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/savsgio/gotils/strconv"
+	"github.com/savsgio/kvbench/internal/common"
+	"github.com/savsgio/kvbench/internal/store"
+	_ "modernc.org/sqlite" // cgo-free driver
+)
+
+// The context of the operations is passed to database/sql natively.
+var _ store.ContextDB = (*DB)(nil)
+
+const (
+	driver = "sqlite"
+
+	// The table is clustered by the key, as a key-value store would be.
+	createTable = "CREATE TABLE IF NOT EXISTS kv (k BLOB PRIMARY KEY, v BLOB) WITHOUT ROWID"
+
+	querySet = "INSERT OR REPLACE INTO kv (k, v) VALUES (?, ?)"
+	queryGet = "SELECT v FROM kv WHERE k = ?"
+	queryDel = "DELETE FROM kv WHERE k = ?"
+)
+
+type DB struct {
+	path  string
+	fsync bool
+	db    *sql.DB
+
+	// In memory, a connection kept open so the database lives as long as
+	// the store, whatever the pool closes.
+	conn *sql.Conn
+
+	set *sql.Stmt
+	get *sql.Stmt
+	del *sql.Stmt
+
+	// SQLite allows a single writer, so the writes wait for each other
+	// here rather than failing as busy (see lock).
+	wmu chan struct{}
+}
+
+func init() {
+	store.Register(store.Provider{
+		Name:   "sqlite",
+		Path:   "sqlite.db",
+		Memory: true,
+		New:    New,
+	})
+}
+
+func New(path string, fsync bool) (store.DB, error) {
+	db := &DB{
+		path:  path,
+		fsync: fsync,
+		wmu:   make(chan struct{}, 1),
+	}
+
+	if err := db.init(); err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// memories numbers the in-memory databases, so each store has its own.
+var memories uint64
+
+func (db *DB) memory() bool {
+	return db.path == store.MemoryPath
+}
+
+// dsn returns the data source name of the store, which sets the pragmas
+// of every connection of the pool.
+func (db *DB) dsn() string {
+	if db.memory() {
+		// The connections share the cache of the named database, where the
+		// reads wait for the lock of the table while a write holds it, and
+		// the driver retries them once it's released. With the write-ahead
+		// log of the files, the reads don't wait for the writes instead.
+		return fmt.Sprintf(
+			"file:kvbench-%d?mode=memory&cache=shared&_pragma=busy_timeout(5000)",
+			atomic.AddUint64(&memories, 1),
+		)
+	}
+
+	synchronous := "OFF"
+	if db.fsync {
+		synchronous = "FULL"
+	}
+
+	return db.path + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=synchronous(" + synchronous + ")"
+}
+
+func (db *DB) init() error {
+	sdb, err := sql.Open(driver, db.dsn())
+	if err != nil {
+		return err
+	}
+
+	db.db = sdb
+
+	if db.memory() {
+		sdb.SetConnMaxLifetime(0)
+		sdb.SetMaxIdleConns(1)
+
+		if db.conn, err = sdb.Conn(context.Background()); err != nil {
+			sdb.Close()
+
+			return err
+		}
+	}
+
+	if _, err := sdb.Exec(createTable); err != nil {
+		db.Close()
+
+		return err
+	}
+
+	for _, s := range []struct {
+		stmt  **sql.Stmt
+		query string
+	}{
+		{&db.set, querySet},
+		{&db.get, queryGet},
+		{&db.del, queryDel},
+	} {
+		if *s.stmt, err = sdb.Prepare(s.query); err != nil {
+			db.Close()
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+// lock waits for the write lock until ctx is done.
+func (db *DB) lock(ctx context.Context) error {
+	select {
+	case db.wmu <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (db *DB) unlock() {
+	<-db.wmu
+}
+
+// update runs fn in a transaction.
+func (db *DB) update(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	if err := db.lock(ctx); err != nil {
+		return err
+	}
+
+	defer db.unlock()
+
+	tx, err := db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (db *DB) Set(key, value []byte) error {
+	return db.SetContext(context.Background(), key, value)
+}
+
+func (db *DB) SetContext(ctx context.Context, key, value []byte) error {
+	if len(key) == 0 {
+		return store.ErrEmptyKey
+	}
+
+	if err := db.lock(ctx); err != nil {
+		return err
+	}
+
+	defer db.unlock()
+
+	_, err := db.set.ExecContext(ctx, key, value)
+
+	return err
+}
+
+func (db *DB) SetString(key string, value []byte) error {
+	return db.Set(strconv.S2B(key), value)
+}
+
+func (db *DB) SetBulk(kvs ...common.KV) error {
+	return db.SetBulkContext(context.Background(), kvs...)
+}
+
+func (db *DB) SetBulkContext(ctx context.Context, kvs ...common.KV) error {
+	return db.update(ctx, func(tx *sql.Tx) error {
+		set := tx.StmtContext(ctx, db.set)
+
+		for i := range kvs {
+			kv := kvs[i]
+
+			if len(kv.Key) == 0 {
+				return store.ErrEmptyKey
+			}
+
+			if _, err := set.ExecContext(ctx, kv.Key, kv.Value); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (db *DB) Get(key []byte) ([]byte, error) {
+	return db.GetContext(context.Background(), key)
+}
+
+func (db *DB) GetContext(ctx context.Context, key []byte) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, store.ErrEmptyKey
+	}
+
+	var value []byte
+
+	err := db.get.QueryRowContext(ctx, key).Scan(&value)
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, store.ErrNotFound
+	case err != nil:
+		return nil, err
+	}
+
+	return value, nil
+}
+
+func (db *DB) GetString(key string) ([]byte, error) {
+	return db.Get(strconv.S2B(key))
+}
+
+func (db *DB) View(key []byte, fn common.ViewFunc) error {
+	return db.ViewContext(context.Background(), key, fn)
+}
+
+// ViewContext scans the value as sql.RawBytes, which the driver still
+// copies out of SQLite, but isn't copied again. It calls fn in the calling
+// goroutine, so never after it returns.
+func (db *DB) ViewContext(ctx context.Context, key []byte, fn common.ViewFunc) error {
+	if len(key) == 0 {
+		return store.ErrEmptyKey
+	}
+
+	rows, err := db.get.QueryContext(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		return store.ErrNotFound
+	}
+
+	var value sql.RawBytes
+
+	if err := rows.Scan(&value); err != nil {
+		return err
+	}
+
+	return fn(value)
+}
+
+func (db *DB) GetBulk(keys ...[]byte) ([]common.KV, error) {
+	return db.GetBulkContext(context.Background(), keys...)
+}
+
+func (db *DB) GetBulkContext(ctx context.Context, keys ...[]byte) ([]common.KV, error) {
+	kvs := make([]common.KV, len(keys))
+
+	tx, err := db.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+
+	// The transaction is only read.
+	defer tx.Rollback()
+
+	get := tx.StmtContext(ctx, db.get)
+
+	for i := range keys {
+		key := keys[i]
+
+		if len(key) == 0 {
+			return nil, store.ErrEmptyKey
+		}
+
+		kv := &kvs[i]
+		kv.Key = append(kv.Key, key...)
+
+		err := get.QueryRowContext(ctx, key).Scan(&kv.Value)
+
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			continue
+		case err != nil:
+			return nil, err
+		}
+
+		kv.Found = true
+	}
+
+	return kvs, nil
+}
+
+func (db *DB) Del(key []byte) error {
+	return db.DelContext(context.Background(), key)
+}
+
+func (db *DB) DelContext(ctx context.Context, key []byte) error {
+	if len(key) == 0 {
+		return store.ErrEmptyKey
+	}
+
+	if err := db.lock(ctx); err != nil {
+		return err
+	}
+
+	defer db.unlock()
+
+	_, err := db.del.ExecContext(ctx, key)
+
+	return err
+}
+
+func (db *DB) DelString(key string) error {
+	return db.Del(strconv.S2B(key))
+}
+
+func (db *DB) DelBulk(keys ...[]byte) error {
+	return db.DelBulkContext(context.Background(), keys...)
+}
+
+func (db *DB) DelBulkContext(ctx context.Context, keys ...[]byte) error {
+	return db.update(ctx, func(tx *sql.Tx) error {
+		del := tx.StmtContext(ctx, db.del)
+
+		for i := range keys {
+			key := keys[i]
+
+			if len(key) == 0 {
+				return store.ErrEmptyKey
+			}
+
+			if _, err := del.ExecContext(ctx, key); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// iter calls fn with the rows of the query, scanned as sql.RawBytes,
+// which are only valid until the next row.
+func (db *DB) iter(ctx context.Context, fn common.IterFunc, query string, args ...interface{}) error {
+	rows, err := db.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	var key, value sql.RawBytes
+
+	for rows.Next() {
+		if err := rows.Scan(&key, &value); err != nil {
+			return err
+		}
+
+		if err := fn(key, value); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (db *DB) Iter(fn common.IterFunc) error {
+	return db.IterContext(context.Background(), fn)
+}
+
+func (db *DB) IterContext(ctx context.Context, fn common.IterFunc) error {
+	return db.iter(ctx, fn, "SELECT k, v FROM kv")
+}
+
+func (db *DB) IterRange(r store.Range, fn common.IterFunc) error {
+	return db.IterRangeContext(context.Background(), r, fn)
+}
+
+func (db *DB) IterRangeContext(ctx context.Context, r store.Range, fn common.IterFunc) error {
+	var (
+		where []string
+		args  []interface{}
+	)
+
+	if len(r.Start) > 0 {
+		where = append(where, "k >= ?")
+		args = append(args, r.Start)
+	}
+
+	if len(r.End) > 0 {
+		where = append(where, "k < ?")
+		args = append(args, r.End)
+	}
+
+	query := "SELECT k, v FROM kv"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+
+	if r.Reverse {
+		query += " ORDER BY k DESC"
+	} else {
+		query += " ORDER BY k"
+	}
+
+	if r.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, r.Limit)
+	}
+
+	return db.iter(ctx, fn, query, args...)
+}
+
+// Sync syncs the write-ahead log and the database file, through other
+// descriptors, since SQLite doesn't sync them with synchronous off.
+func (db *DB) Sync() error {
+	if db.memory() {
+		return nil
+	}
+
+	for _, path := range []string{db.path + "-wal", db.path} {
+		f, err := os.Open(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		err = f.Sync()
+		f.Close()
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SyncContext abandons the fsyncs when ctx is done, since they can't
+// be interrupted.
+func (db *DB) SyncContext(ctx context.Context) error {
+	return store.Do(ctx, db.Sync)
+}
+
+func (db *DB) Flush() error {
+	return db.FlushContext(context.Background())
+}
+
+// FlushContext checkpoints the write-ahead log into the database file.
+func (db *DB) FlushContext(ctx context.Context) error {
+	if db.memory() {
+		return nil
+	}
+
+	if err := db.lock(ctx); err != nil {
+		return err
+	}
+
+	defer db.unlock()
+
+	_, err := db.db.ExecContext(ctx, "PRAGMA wal_checkpoint(TRUNCATE)")
+
+	return err
+}
+
+func (db *DB) Reset() error {
+	db.lock(context.Background())
+	defer db.unlock()
+
+	_, err := db.db.Exec("DELETE FROM kv")
+
+	return err
+}
+
+func (db *DB) Close() error {
+	for _, stmt := range []*sql.Stmt{db.set, db.get, db.del} {
+		if stmt != nil {
+			stmt.Close()
+		}
+	}
+
+	if db.conn != nil {
+		db.conn.Close()
+	}
+
+	return db.db.Close()
+}
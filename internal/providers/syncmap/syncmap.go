@@ -0,0 +1,187 @@
+package syncmap
+
+import (
+	"sync"
+
+	"github.com/savsgio/gotils/strconv"
+	"github.com/savsgio/kvbench/internal/common"
+	"github.com/savsgio/kvbench/internal/store"
+)
+
+// DB is a sync.Map of string keys and []byte values, which is optimized
+// for keys written once and read many times.
+type DB struct {
+	m sync.Map
+}
+
+func init() {
+	store.Register(store.Provider{
+		Name:     "syncmap",
+		Memory:   true,
+		Volatile: true,
+		Lacks:    store.CapOrderedIter,
+		New:      New,
+	})
+}
+
+// New returns an empty store, ignoring the path and the fsync flag.
+func New(path string, fsync bool) (store.DB, error) {
+	return &DB{}, nil
+}
+
+func (db *DB) Set(key, value []byte) error {
+	if len(key) == 0 {
+		return store.ErrEmptyKey
+	}
+
+	// Store a copy of the value, since the caller may reuse it.
+	db.m.Store(string(key), append([]byte{}, value...))
+
+	return nil
+}
+
+func (db *DB) SetString(key string, value []byte) error {
+	return db.Set(strconv.S2B(key), value)
+}
+
+// SetBulk sets the entries one by one, so the bulk isn't atomic.
+func (db *DB) SetBulk(kvs ...common.KV) error {
+	for i := range kvs {
+		if len(kvs[i].Key) == 0 {
+			return store.ErrEmptyKey
+		}
+	}
+
+	for i := range kvs {
+		if err := db.Set(kvs[i].Key, kvs[i].Value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (db *DB) Get(key []byte) ([]byte, error) {
+	var value []byte
+
+	err := db.View(key, func(v []byte) error {
+		value = append([]byte{}, v...)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+func (db *DB) GetString(key string) ([]byte, error) {
+	return db.Get(strconv.S2B(key))
+}
+
+// View calls fn with the stored value, which is never modified, but
+// replaced by the writes.
+func (db *DB) View(key []byte, fn common.ViewFunc) error {
+	if len(key) == 0 {
+		return store.ErrEmptyKey
+	}
+
+	v, ok := db.m.Load(strconv.B2S(key))
+	if !ok {
+		return store.ErrNotFound
+	}
+
+	return fn(v.([]byte))
+}
+
+func (db *DB) GetBulk(keys ...[]byte) ([]common.KV, error) {
+	kvs := make([]common.KV, len(keys))
+
+	for i := range keys {
+		key := keys[i]
+
+		if len(key) == 0 {
+			return nil, store.ErrEmptyKey
+		}
+
+		kv := &kvs[i]
+		kv.Key = append(kv.Key, key...)
+
+		v, ok := db.m.Load(strconv.B2S(key))
+		if !ok {
+			continue
+		}
+
+		kv.Value = append(kv.Value, v.([]byte)...)
+		kv.Found = true
+	}
+
+	return kvs, nil
+}
+
+func (db *DB) Del(key []byte) error {
+	if len(key) == 0 {
+		return store.ErrEmptyKey
+	}
+
+	db.m.Delete(strconv.B2S(key))
+
+	return nil
+}
+
+func (db *DB) DelString(key string) error {
+	return db.Del(strconv.S2B(key))
+}
+
+func (db *DB) DelBulk(keys ...[]byte) error {
+	for i := range keys {
+		if len(keys[i]) == 0 {
+			return store.ErrEmptyKey
+		}
+	}
+
+	for i := range keys {
+		db.m.Delete(strconv.B2S(keys[i]))
+	}
+
+	return nil
+}
+
+// Iter calls fn with the entries in no order, which aren't a consistent
+// snapshot if the store is written meanwhile.
+func (db *DB) Iter(fn common.IterFunc) (err error) {
+	db.m.Range(func(k, v interface{}) bool {
+		err = fn(strconv.S2B(k.(string)), v.([]byte))
+
+		return err == nil
+	})
+
+	return err
+}
+
+func (db *DB) IterRange(r store.Range, fn common.IterFunc) error {
+	return store.IterUnordered(r, db.Iter, fn)
+}
+
+func (db *DB) Sync() error {
+	return nil
+}
+
+func (db *DB) Flush() error {
+	return nil
+}
+
+func (db *DB) Reset() error {
+	db.m.Range(func(k, v interface{}) bool {
+		db.m.Delete(k)
+
+		return true
+	})
+
+	return nil
+}
+
+func (db *DB) Close() error {
+	return db.Reset()
+}
@@ -0,0 +1,151 @@
+// Package result defines the structured results of the benchmarks
+// and how they are written out.
+package result
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/savsgio/kvbench/internal/histogram"
+)
+
+// Result holds the measures of a benchmark phase.
+type Result struct {
+	// Engine is the name of the store provider.
+	Engine string `json:"engine"`
+
+	// Mode describes how the store was opened (e.g. nofsync, memory/fsync).
+	Mode string `json:"mode"`
+
+	// Phase is the name of the benchmark phase (e.g. set, get).
+	Phase string `json:"phase"`
+
+	// Records is the number of records loaded before the phase.
+	Records uint64 `json:"records"`
+
+	// KeySize is the size in bytes of the keys.
+	KeySize int `json:"key_size"`
+
+	// ValueSize is the mean size in bytes of the written values.
+	ValueSize int `json:"value_size"`
+
+	// ValueDist is the distribution of the value sizes,
+	// empty if all of them are of ValueSize.
+	ValueDist string `json:"value_dist,omitempty"`
+
+	// Ops is the number of completed operations, including the ones of
+	// a missing key, so the successful ones are Ops - NotFound.
+	Ops uint64 `json:"ops"`
+
+	// NotFound is the number of completed reads of a missing key.
+	NotFound uint64 `json:"not_found"`
+
+	// HitRatio is the fraction of the completed reads whose key was found,
+	// nil for the phases other than reads.
+	HitRatio *float64 `json:"hit_ratio,omitempty"`
+
+	// EvictionMissRatio is the fraction of the completed reads whose key
+	// was evicted, nil for the phases other than reads and for the engines
+	// that keep every key.
+	EvictionMissRatio *float64 `json:"eviction_miss_ratio,omitempty"`
+
+	// DroppedWrites reports whether the engine may drop writes, so the
+	// misses of EvictionMissRatio include them besides the evictions.
+	DroppedWrites bool `json:"dropped_writes,omitempty"`
+
+	// Timeouts is the number of operations abandoned by the operation
+	// timeout, which are not counted in Ops nor measured in Latency.
+	Timeouts uint64 `json:"timeouts"`
+
+	// Errors is the number of failed operations by error type, which are
+	// not counted in Ops nor measured in Latency.
+	Errors map[string]uint64 `json:"errors,omitempty"`
+
+	// Duration is the elapsed time of the phase.
+	Duration time.Duration `json:"duration_ns"`
+
+	// Throughput is the number of operations per second.
+	Throughput float64 `json:"throughput"`
+
+	// TargetRate is the scheduled number of operations per second,
+	// zero if the operations run as fast as possible.
+	TargetRate float64 `json:"target_rate"`
+
+	// Latency of each operation.
+	Latency histogram.Summary `json:"latency"`
+
+	// Memory of the process during the phase, nil for the results of
+	// a kind of operation of a workload.
+	Memory *Memory `json:"memory,omitempty"`
+
+	// Timeline of the phase, sampled by intervals.
+	Timeline []Sample `json:"timeline,omitempty"`
+}
+
+// Sample holds the measures of an interval of a phase.
+type Sample struct {
+	// Offset is the start of the interval since the start of the phase.
+	Offset time.Duration `json:"offset_ns"`
+
+	// Ops is the number of operations completed in the interval.
+	Ops uint64 `json:"ops"`
+
+	// Throughput is the number of operations per second.
+	Throughput float64 `json:"throughput"`
+
+	// Latency of the operations completed in the interval.
+	Latency histogram.Summary `json:"latency"`
+
+	// HeapInuse is the peak heap in use sampled in the interval.
+	HeapInuse uint64 `json:"heap_inuse"`
+}
+
+// Memory holds the allocation and GC measures of the process during
+// a phase, which include the ones of any concurrent phase.
+type Memory struct {
+	// AllocBytes is the number of bytes allocated.
+	AllocBytes uint64 `json:"alloc_bytes"`
+
+	// Allocs is the number of heap allocations.
+	Allocs uint64 `json:"allocs"`
+
+	// AllocsPerOp is the number of heap allocations per operation.
+	AllocsPerOp float64 `json:"allocs_per_op"`
+
+	// BytesPerOp is the number of bytes allocated per operation.
+	BytesPerOp float64 `json:"bytes_per_op"`
+
+	// GCCycles is the number of completed GC cycles.
+	GCCycles uint32 `json:"gc_cycles"`
+
+	// GCPause is the total stop-the-world pause of the GC.
+	GCPause time.Duration `json:"gc_pause_ns"`
+
+	// PeakHeap is the peak heap in use sampled during the phase.
+	PeakHeap uint64 `json:"peak_heap_inuse"`
+}
+
+// ErrorCount returns the number of failed operations.
+func (r Result) ErrorCount() uint64 {
+	var n uint64
+
+	for _, c := range r.Errors {
+		n += c
+	}
+
+	return n
+}
+
+// Name returns the engine and the mode of the result.
+func (r Result) Name() string {
+	return r.Engine + "/" + r.Mode
+}
+
+// valueSize returns the description of the value sizes.
+func (r Result) valueSize() string {
+	if r.ValueDist != "" {
+		return r.ValueDist
+	}
+
+	return strconv.Itoa(r.ValueSize) + " B"
+}
@@ -0,0 +1,353 @@
+package result
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/savsgio/kvbench/internal/histogram"
+)
+
+// Supported output formats.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+// Writer writes results in a given format.
+type Writer interface {
+	Write(r Result) error
+
+	// Flush writes any buffered result to the underlying writer.
+	Flush() error
+}
+
+// NewWriter returns a writer of the given format.
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatText:
+		return &textWriter{w: w}, nil
+	case FormatJSON:
+		return &jsonWriter{w: w}, nil
+	case FormatCSV:
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	default:
+		return nil, fmt.Errorf("unknown output format: %v", format)
+	}
+}
+
+type textWriter struct {
+	w io.Writer
+}
+
+func (tw *textWriter) Write(r Result) error {
+	l := r.Latency
+
+	target := ""
+	if r.TargetRate > 0 {
+		target = fmt.Sprintf(", target: %d op/s", int64(r.TargetRate))
+	}
+
+	allocs := ""
+	if m := r.Memory; m != nil {
+		allocs = fmt.Sprintf(", allocs: %.1f/op (%d B/op)", m.AllocsPerOp, int64(m.BytesPerOp))
+	}
+
+	counters := ""
+	if r.HitRatio != nil {
+		counters += fmt.Sprintf(", hit ratio: %.2f%%", *r.HitRatio*100)
+	}
+
+	if r.EvictionMissRatio != nil {
+		counters += fmt.Sprintf(", eviction misses: %.2f%%", *r.EvictionMissRatio*100)
+
+		if r.DroppedWrites {
+			counters += " (with dropped writes)"
+		}
+	}
+
+	if r.NotFound > 0 {
+		counters += fmt.Sprintf(", not found: %d", r.NotFound)
+	}
+
+	if r.Timeouts > 0 {
+		counters += fmt.Sprintf(", timeouts: %d", r.Timeouts)
+	}
+
+	if len(r.Errors) > 0 {
+		counters += fmt.Sprintf(", errors: %d (%s)", r.ErrorCount(), formatErrors(r.Errors))
+	}
+
+	_, err := fmt.Fprintf(
+		tw.w,
+		"%s %s size: %s%s, rate: %d op/s%s%s, mean: %d ns, took: %d s, p50: %d ns, p90: %d ns, p99: %d ns, p99.9: %d ns, max: %d ns\n",
+		r.Name(), r.Phase, r.valueSize(), target, int64(r.Throughput), allocs, counters, l.Mean, int(r.Duration.Seconds()),
+		l.P50, l.P90, l.P99, l.P999, l.Max,
+	)
+
+	return err
+}
+
+func (tw *textWriter) Flush() error {
+	return nil
+}
+
+type jsonWriter struct {
+	w       io.Writer
+	results []Result
+}
+
+func (jw *jsonWriter) Write(r Result) error {
+	jw.results = append(jw.results, r)
+
+	return nil
+}
+
+func (jw *jsonWriter) Flush() error {
+	if jw.results == nil {
+		jw.results = []Result{}
+	}
+
+	enc := json.NewEncoder(jw.w)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(jw.results); err != nil {
+		return err
+	}
+
+	jw.results = nil
+
+	return nil
+}
+
+var (
+	csvRunHeader = []string{
+		"engine", "mode", "phase", "records", "key_size", "value_size", "value_dist", "target_rate",
+	}
+
+	csvLatencyHeader = []string{
+		"mean_ns", "min_ns", "p50_ns", "p90_ns", "p99_ns", "p999_ns", "max_ns",
+	}
+
+	csvMemoryHeader = []string{
+		"alloc_bytes", "allocs", "allocs_per_op", "bytes_per_op", "gc_cycles", "gc_pause_ns", "peak_heap_inuse",
+	}
+
+	csvHeader = concat(
+		csvRunHeader,
+		[]string{
+			"ops", "not_found", "hit_ratio", "eviction_miss_ratio", "dropped_writes", "timeouts", "errors", "error_types",
+			"duration_ns", "throughput",
+		},
+		csvLatencyHeader, csvMemoryHeader,
+	)
+
+	csvTimelineHeader = concat(
+		csvRunHeader, []string{"offset_ns", "ops", "throughput"}, csvLatencyHeader, []string{"heap_inuse"},
+	)
+)
+
+func concat(ss ...[]string) []string {
+	var r []string
+
+	for _, s := range ss {
+		r = append(r, s...)
+	}
+
+	return r
+}
+
+func formatDuration(d time.Duration) string {
+	return strconv.FormatInt(int64(d), 10)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', 2, 64)
+}
+
+// formatRatio returns the ratio with 4 decimals, or empty if it's nil.
+func formatRatio(f *float64) string {
+	if f == nil {
+		return ""
+	}
+
+	return strconv.FormatFloat(*f, 'f', 4, 64)
+}
+
+// formatErrors returns the counts of the error types sorted by type,
+// as "type=count" separated by semicolons.
+func formatErrors(errs map[string]uint64) string {
+	types := make([]string, 0, len(errs))
+	for typ := range errs {
+		types = append(types, typ)
+	}
+
+	sort.Strings(types)
+
+	for i, typ := range types {
+		types[i] = typ + "=" + strconv.FormatUint(errs[typ], 10)
+	}
+
+	return strings.Join(types, "; ")
+}
+
+func csvRun(r Result) []string {
+	return []string{
+		r.Engine,
+		r.Mode,
+		r.Phase,
+		strconv.FormatUint(r.Records, 10),
+		strconv.Itoa(r.KeySize),
+		strconv.Itoa(r.ValueSize),
+		r.ValueDist,
+		formatFloat(r.TargetRate),
+	}
+}
+
+func csvLatency(l histogram.Summary) []string {
+	return []string{
+		formatDuration(l.Mean),
+		formatDuration(l.Min),
+		formatDuration(l.P50),
+		formatDuration(l.P90),
+		formatDuration(l.P99),
+		formatDuration(l.P999),
+		formatDuration(l.Max),
+	}
+}
+
+// csvMemory returns the memory columns, empty if m is nil.
+func csvMemory(m *Memory) []string {
+	if m == nil {
+		return make([]string, len(csvMemoryHeader))
+	}
+
+	return []string{
+		strconv.FormatUint(m.AllocBytes, 10),
+		strconv.FormatUint(m.Allocs, 10),
+		formatFloat(m.AllocsPerOp),
+		formatFloat(m.BytesPerOp),
+		strconv.FormatUint(uint64(m.GCCycles), 10),
+		formatDuration(m.GCPause),
+		strconv.FormatUint(m.PeakHeap, 10),
+	}
+}
+
+type csvWriter struct {
+	w      *csv.Writer
+	header bool
+}
+
+func (cw *csvWriter) Write(r Result) error {
+	if !cw.header {
+		if err := cw.w.Write(csvHeader); err != nil {
+			return err
+		}
+
+		cw.header = true
+	}
+
+	return cw.w.Write(concat(
+		csvRun(r),
+		[]string{
+			strconv.FormatUint(r.Ops, 10),
+			strconv.FormatUint(r.NotFound, 10),
+			formatRatio(r.HitRatio),
+			formatRatio(r.EvictionMissRatio),
+			strconv.FormatBool(r.DroppedWrites),
+			strconv.FormatUint(r.Timeouts, 10),
+			strconv.FormatUint(r.ErrorCount(), 10),
+			formatErrors(r.Errors),
+			formatDuration(r.Duration),
+			formatFloat(r.Throughput),
+		},
+		csvLatency(r.Latency),
+		csvMemory(r.Memory),
+	))
+}
+
+func (cw *csvWriter) Flush() error {
+	cw.w.Flush()
+
+	return cw.w.Error()
+}
+
+// NewTimelineWriter returns a writer of the timelines of the results
+// as csv, with a row per sample.
+func NewTimelineWriter(w io.Writer) Writer {
+	return &timelineWriter{w: csv.NewWriter(w)}
+}
+
+type timelineWriter struct {
+	w      *csv.Writer
+	header bool
+}
+
+func (tw *timelineWriter) Write(r Result) error {
+	if !tw.header {
+		if err := tw.w.Write(csvTimelineHeader); err != nil {
+			return err
+		}
+
+		tw.header = true
+	}
+
+	run := csvRun(r)
+
+	for _, s := range r.Timeline {
+		if err := tw.w.Write(concat(
+			run,
+			[]string{
+				formatDuration(s.Offset),
+				strconv.FormatUint(s.Ops, 10),
+				formatFloat(s.Throughput),
+			},
+			csvLatency(s.Latency),
+			[]string{strconv.FormatUint(s.HeapInuse, 10)},
+		)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (tw *timelineWriter) Flush() error {
+	tw.w.Flush()
+
+	return tw.w.Error()
+}
+
+// MultiWriter returns a writer that writes the results to all the given
+// writers.
+func MultiWriter(ws ...Writer) Writer {
+	return multiWriter(ws)
+}
+
+type multiWriter []Writer
+
+func (mw multiWriter) Write(r Result) error {
+	for _, w := range mw {
+		if err := w.Write(r); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (mw multiWriter) Flush() error {
+	for _, w := range mw {
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
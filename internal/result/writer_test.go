@@ -0,0 +1,139 @@
+package result
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/savsgio/kvbench/internal/histogram"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func ratio(f float64) *float64 {
+	return &f
+}
+
+// testResults returns a read result with every measure, and a write one
+// of a value distribution with a comma, which the csv must quote.
+func testResults() []Result {
+	latency := histogram.Summary{
+		Count: 1000,
+		Min:   100,
+		Mean:  1500,
+		P50:   1200,
+		P90:   2500,
+		P99:   7000,
+		P999:  9000,
+		Max:   12000,
+	}
+
+	return []Result{
+		{
+			Engine:            "ristretto",
+			Mode:              "memory/nofsync",
+			Phase:             "get",
+			Records:           1000,
+			KeySize:           16,
+			ValueSize:         256,
+			Ops:               1000,
+			NotFound:          100,
+			HitRatio:          ratio(0.9),
+			EvictionMissRatio: ratio(0.1),
+			DroppedWrites:     true,
+			Timeouts:          2,
+			Errors:            map[string]uint64{"unsupported": 1, "*errors.errorString": 3},
+			Duration:          2 * time.Second,
+			Throughput:        500,
+			TargetRate:        1000,
+			Latency:           latency,
+			Memory: &Memory{
+				AllocBytes:  256000,
+				Allocs:      2000,
+				AllocsPerOp: 2,
+				BytesPerOp:  256,
+				GCCycles:    3,
+				GCPause:     150 * time.Microsecond,
+				PeakHeap:    1 << 20,
+			},
+			Timeline: []Sample{
+				{Offset: 0, Ops: 600, Throughput: 600, Latency: latency, HeapInuse: 1 << 20},
+				{Offset: time.Second, Ops: 400, Throughput: 400, Latency: latency, HeapInuse: 1 << 19},
+			},
+		},
+		{
+			Engine:     "leveldb",
+			Mode:       "fsync",
+			Phase:      "set",
+			Records:    1000,
+			KeySize:    16,
+			ValueSize:  64,
+			ValueDist:  "hist:sizes,v2.txt",
+			Ops:        1000,
+			Duration:   time.Second,
+			Throughput: 1000,
+			Latency:    latency,
+		},
+	}
+}
+
+func testWriter(t *testing.T, golden string, newWriter func(w *bytes.Buffer) Writer) {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	w := newWriter(&buf)
+
+	for _, r := range testResults() {
+		if err := w.Write(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join("testdata", golden)
+
+	if *update {
+		if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := buf.Bytes(); !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+func TestWriter(t *testing.T) {
+	for _, format := range []string{FormatText, FormatJSON, FormatCSV} {
+		format := format
+
+		t.Run(format, func(t *testing.T) {
+			testWriter(t, format+".golden", func(buf *bytes.Buffer) Writer {
+				w, err := NewWriter(format, buf)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				return w
+			})
+		})
+	}
+}
+
+func TestTimelineWriter(t *testing.T) {
+	testWriter(t, "timeline.golden", func(buf *bytes.Buffer) Writer {
+		return NewTimelineWriter(buf)
+	})
+}
@@ -0,0 +1,227 @@
+package store
+
+import (
+	"context"
+	"sync"
+
+	"github.com/savsgio/kvbench/internal/common"
+)
+
+// ContextDB is the variant of DB whose operations return when their
+// context is done, with the error of the context.
+type ContextDB interface {
+	DB
+	SetContext(ctx context.Context, key, value []byte) error
+	SetBulkContext(ctx context.Context, kvs ...common.KV) error
+	GetContext(ctx context.Context, key []byte) ([]byte, error)
+	GetBulkContext(ctx context.Context, keys ...[]byte) ([]common.KV, error)
+
+	// ViewContext is like View, but fn may still run after it returns with
+	// the error of ctx, so fn must neither keep value nor write anything
+	// the caller reads after the return.
+	ViewContext(ctx context.Context, key []byte, fn common.ViewFunc) error
+
+	DelContext(ctx context.Context, key []byte) error
+	DelBulkContext(ctx context.Context, keys ...[]byte) error
+	IterContext(ctx context.Context, fn common.IterFunc) error
+	IterRangeContext(ctx context.Context, r Range, fn common.IterFunc) error
+	SyncContext(ctx context.Context) error
+	FlushContext(ctx context.Context) error
+}
+
+// WithContext returns db as a ContextDB.
+//
+// If db doesn't implement it, the operations with a context that can be
+// done run in their own goroutine (see Do). The ones with a context that
+// can't be done, such as context.Background(), call db directly, without
+// allocating.
+func WithContext(db DB) ContextDB {
+	if cdb, ok := db.(ContextDB); ok {
+		return cdb
+	}
+
+	return contextDB{db}
+}
+
+type contextDB struct {
+	DB
+}
+
+// running counts the calls of Do running in background.
+var running sync.WaitGroup
+
+// Do runs fn until ctx is done, in its own goroutine, so it's abandoned
+// when ctx is done, although fn completes in background (see Wait). If
+// ctx can't be done, fn runs in the calling goroutine.
+func Do(ctx context.Context, fn func() error) error {
+	if ctx.Done() == nil {
+		return fn()
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+
+	running.Add(1)
+
+	go func() {
+		defer running.Done()
+
+		done <- fn()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Wait waits for the calls of Do to return, including the abandoned ones,
+// which would otherwise still use their store after the caller moves on
+// or closes it. It must not be called concurrently with Do.
+func Wait() {
+	running.Wait()
+}
+
+func (db contextDB) SetContext(ctx context.Context, key, value []byte) error {
+	if ctx.Done() == nil {
+		return db.Set(key, value)
+	}
+
+	return Do(ctx, func() error {
+		return db.Set(key, value)
+	})
+}
+
+func (db contextDB) SetBulkContext(ctx context.Context, kvs ...common.KV) error {
+	if ctx.Done() == nil {
+		return db.SetBulk(kvs...)
+	}
+
+	return Do(ctx, func() error {
+		return db.SetBulk(kvs...)
+	})
+}
+
+// GetContext only reads the value if the call completes, since an
+// abandoned one sets it in background.
+func (db contextDB) GetContext(ctx context.Context, key []byte) ([]byte, error) {
+	if ctx.Done() == nil {
+		return db.Get(key)
+	}
+
+	var value []byte
+
+	err := Do(ctx, func() (err error) {
+		value, err = db.Get(key)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+func (db contextDB) GetBulkContext(ctx context.Context, keys ...[]byte) ([]common.KV, error) {
+	if ctx.Done() == nil {
+		return db.GetBulk(keys...)
+	}
+
+	var kvs []common.KV
+
+	err := Do(ctx, func() (err error) {
+		kvs, err = db.GetBulk(keys...)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return kvs, nil
+}
+
+// ViewContext may call fn after it returns if ctx is done, from the
+// goroutine of the abandoned call, while the engine still holds value.
+func (db contextDB) ViewContext(ctx context.Context, key []byte, fn common.ViewFunc) error {
+	if ctx.Done() == nil {
+		return db.View(key, fn)
+	}
+
+	return Do(ctx, func() error {
+		return db.View(key, fn)
+	})
+}
+
+func (db contextDB) DelContext(ctx context.Context, key []byte) error {
+	if ctx.Done() == nil {
+		return db.Del(key)
+	}
+
+	return Do(ctx, func() error {
+		return db.Del(key)
+	})
+}
+
+func (db contextDB) DelBulkContext(ctx context.Context, keys ...[]byte) error {
+	if ctx.Done() == nil {
+		return db.DelBulk(keys...)
+	}
+
+	return Do(ctx, func() error {
+		return db.DelBulk(keys...)
+	})
+}
+
+// IterContext checks ctx before each key, in the calling goroutine,
+// so fn is never called after it returns.
+func (db contextDB) IterContext(ctx context.Context, fn common.IterFunc) error {
+	if ctx.Done() == nil {
+		return db.Iter(fn)
+	}
+
+	return db.Iter(func(key, value []byte) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		return fn(key, value)
+	})
+}
+
+// IterRangeContext is like IterContext, but iterating the range r.
+func (db contextDB) IterRangeContext(ctx context.Context, r Range, fn common.IterFunc) error {
+	if ctx.Done() == nil {
+		return db.IterRange(r, fn)
+	}
+
+	return db.IterRange(r, func(key, value []byte) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		return fn(key, value)
+	})
+}
+
+func (db contextDB) SyncContext(ctx context.Context) error {
+	if ctx.Done() == nil {
+		return db.Sync()
+	}
+
+	return Do(ctx, db.Sync)
+}
+
+func (db contextDB) FlushContext(ctx context.Context) error {
+	if ctx.Done() == nil {
+		return db.Flush()
+	}
+
+	return Do(ctx, db.Flush)
+}
@@ -7,4 +7,6 @@ var (
 	ErrUnsupported      = errors.New("unsupported")
 	ErrInit             = errors.New("failed to init")
 	ErrEmptyKey         = errors.New("key cannot be empty")
+	ErrNotFound         = errors.New("key not found")
+	ErrUnknownStore     = errors.New("unknown store type")
 )
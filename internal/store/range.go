@@ -0,0 +1,110 @@
+package store
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/savsgio/kvbench/internal/common"
+)
+
+// Range bounds an iteration to the keys in [Start, End).
+type Range struct {
+	// Start is the first key of the range, inclusive.
+	// If empty, the range starts from the first key.
+	Start []byte
+
+	// End is the key after the range, exclusive.
+	// If empty, the range ends with the last key.
+	End []byte
+
+	// Limit is the maximum number of keys to iterate, zero if unlimited.
+	Limit int
+
+	// Reverse iterates the keys in descending order, starting from the
+	// last key of the range.
+	Reverse bool
+}
+
+// PrefixRange returns the range of the keys starting with prefix.
+func PrefixRange(prefix []byte) Range {
+	r := Range{Start: prefix}
+
+	// The end is the prefix incremented, ignoring its trailing 0xff bytes.
+	for i := len(prefix) - 1; i >= 0; i-- {
+		if prefix[i] < 0xff {
+			r.End = append(r.End, prefix[:i+1]...)
+			r.End[i]++
+
+			break
+		}
+	}
+
+	return r
+}
+
+// Contains reports whether key is in the range.
+func (r Range) Contains(key []byte) bool {
+	if len(r.Start) > 0 && bytes.Compare(key, r.Start) < 0 {
+		return false
+	}
+
+	return len(r.End) == 0 || bytes.Compare(key, r.End) < 0
+}
+
+// Past reports whether key is after the range in the order of the
+// iteration, so no more keys of the range follow it.
+func (r Range) Past(key []byte) bool {
+	if r.Reverse {
+		return len(r.Start) > 0 && bytes.Compare(key, r.Start) < 0
+	}
+
+	return len(r.End) > 0 && bytes.Compare(key, r.End) >= 0
+}
+
+// Full reports whether n keys reach the limit of the range.
+func (r Range) Full(n int) bool {
+	return r.Limit > 0 && n >= r.Limit
+}
+
+// IterUnordered iterates the range r of a store without ordered iteration.
+//
+// It collects a copy of the entries of the range from the full iteration
+// iter, and calls fn with them once sorted, so it costs a full iteration
+// regardless of the bounds of the range.
+func IterUnordered(r Range, iter func(fn common.IterFunc) error, fn common.IterFunc) error {
+	var kvs []common.KV
+
+	err := iter(func(key, value []byte) error {
+		if r.Contains(key) {
+			kvs = append(kvs, common.KV{
+				Key:   append([]byte(nil), key...),
+				Value: append([]byte(nil), value...),
+			})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(kvs, func(i, j int) bool {
+		if r.Reverse {
+			i, j = j, i
+		}
+
+		return bytes.Compare(kvs[i].Key, kvs[j].Key) < 0
+	})
+
+	for i := range kvs {
+		if r.Full(i) {
+			break
+		}
+
+		if err := fn(kvs[i].Key, kvs[i].Value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// MemoryPath is the path that selects the in-memory mode of a provider.
+const MemoryPath = ":memory:"
+
+// Capability is an operation or a guarantee of the engines, which some of
+// them lack.
+type Capability uint
+
+const (
+	// CapIter is the iteration of the keys, by Iter and IterRange.
+	CapIter Capability = 1 << iota
+
+	// CapRetention is the guarantee that a key is kept, and visible to the
+	// reads once its write returns, until it's deleted. The caches lack it,
+	// since they evict keys to bound their size, and may apply the writes
+	// asynchronously, up to the next Sync.
+	CapRetention
+
+	// CapOrderedIter is the iteration of a range in order by IterRange,
+	// at the cost of the keys iterated. The engines lacking it but not
+	// CapIter still iterate the ranges in order, but at the cost of a full
+	// iteration (see IterUnordered) or of the whole range, whatever its
+	// limit.
+	CapOrderedIter
+
+	// CapAdmitAll is the guarantee that every write is applied. Ristretto
+	// lacks it, since it drops the writes when its buffers are full or its
+	// admission policy rejects them.
+	CapAdmitAll
+)
+
+// Factory opens a store at the given path.
+type Factory func(path string, fsync bool) (DB, error)
+
+// Provider describes a registered store engine.
+type Provider struct {
+	// Name used to select the engine (e.g. kvbench -s).
+	Name string
+
+	// Default on-disk path of the engine.
+	Path string
+
+	// Memory reports whether the engine supports the MemoryPath.
+	Memory bool
+
+	// Volatile reports whether the engine only keeps its data in memory,
+	// whatever the path, so the data is lost when the store is closed.
+	Volatile bool
+
+	// Lacks are the capabilities the engine doesn't have, none by default.
+	// The operations of the missing capabilities return ErrUnsupported.
+	Lacks Capability
+
+	New Factory
+}
+
+var (
+	providersMu sync.RWMutex
+	providers   = make(map[string]Provider)
+)
+
+// Register makes a provider available by its name.
+//
+// It panics if the provider is invalid or its name is already registered,
+// so it's meant to be called from the init function of the provider package.
+func Register(p Provider) {
+	if p.Name == "" || p.New == nil {
+		panic("store: invalid provider")
+	}
+
+	providersMu.Lock()
+	defer providersMu.Unlock()
+
+	if _, ok := providers[p.Name]; ok {
+		panic("store: provider already registered: " + p.Name)
+	}
+
+	providers[p.Name] = p
+}
+
+// Lookup returns the provider registered with the given name.
+func Lookup(name string) (Provider, error) {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+
+	p, ok := providers[name]
+	if !ok {
+		return Provider{}, fmt.Errorf("%w: %s", ErrUnknownStore, name)
+	}
+
+	return p, nil
+}
+
+// Providers returns all the registered providers sorted by name.
+func Providers() []Provider {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+
+	ps := make([]Provider, 0, len(providers))
+	for _, p := range providers {
+		ps = append(ps, p)
+	}
+
+	sort.Slice(ps, func(i, j int) bool {
+		return ps[i].Name < ps[j].Name
+	})
+
+	return ps
+}
+
+// Names returns the names of all the registered providers sorted.
+func Names() []string {
+	ps := Providers()
+	names := make([]string, len(ps))
+
+	for i := range ps {
+		names[i] = ps[i].Name
+	}
+
+	return names
+}
+
+// Supports reports whether the engine has the capability c.
+func (p Provider) Supports(c Capability) bool {
+	return p.Lacks&c == 0
+}
+
+// Open opens the store of the provider.
+//
+// If path is empty, the default path of the provider is used.
+func (p Provider) Open(path string, fsync bool) (DB, string, error) {
+	if path == "" {
+		path = p.Path
+	}
+
+	if path == MemoryPath && !p.Memory {
+		return nil, path, ErrMemoryNotAllowed
+	}
+
+	db, err := p.New(path, fsync)
+
+	return db, path, err
+}
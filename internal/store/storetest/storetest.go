@@ -0,0 +1,716 @@
+// Package storetest implements a conformance suite of the store.DB
+// implementations.
+package storetest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"testing"
+
+	"github.com/savsgio/kvbench/internal/common"
+	"github.com/savsgio/kvbench/internal/store"
+)
+
+var errStop = errors.New("stop iteration")
+
+// Key returns the i-th key of the tests, which sort as i.
+func Key(i int) []byte {
+	r := make([]byte, 8)
+	binary.BigEndian.PutUint64(r, uint64(i))
+
+	return r
+}
+
+// Value returns the value of the i-th key of the tests,
+// which changes with each version of the key.
+func Value(i, version int) []byte {
+	return []byte(fmt.Sprintf("value-%d-%d", i, version))
+}
+
+type suite struct {
+	p      store.Provider
+	fsync  bool
+	memory bool
+	count  int
+}
+
+// Run runs the conformance suite on the provider p with count keys,
+// opening a new store in a temporary directory for each test.
+func Run(t *testing.T, p store.Provider, fsync bool, count int) {
+	(&suite{p: p, fsync: fsync, count: count}).run(t)
+}
+
+// RunMemory runs the conformance suite on the in-memory mode of the
+// provider p with count keys, opening a new store for each test.
+func RunMemory(t *testing.T, p store.Provider, count int) {
+	if !p.Memory {
+		t.Skip("in-memory mode unsupported")
+	}
+
+	(&suite{p: p, memory: true, count: count}).run(t)
+}
+
+func (s *suite) run(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, path string)
+	}{
+		{"SetGet", s.testSetGet},
+		{"Bulk", s.testBulk},
+		{"Missing", s.testMissing},
+		{"EmptyValue", s.testEmptyValue},
+		{"View", s.testView},
+		{"EmptyKey", s.testEmptyKey},
+		{"Delete", s.testDelete},
+		{"Iter", s.testIter},
+		{"IterRange", s.testIterRange},
+		{"SyncFlush", s.testSyncFlush},
+		{"Reset", s.testReset},
+		{"Close", s.testClose},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			path := store.MemoryPath
+			if !s.memory {
+				path = filepath.Join(t.TempDir(), s.p.Path)
+			}
+
+			test.fn(t, path)
+		})
+	}
+}
+
+// open opens the store at path, which is closed at the end of the test.
+func (s *suite) open(t *testing.T, path string) store.DB {
+	t.Helper()
+
+	db := s.openUnmanaged(t, path)
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+func (s *suite) openUnmanaged(t *testing.T, path string) store.DB {
+	t.Helper()
+
+	db, _, err := s.p.Open(path, s.fsync)
+	if err != nil {
+		t.Fatalf("failed to open %s: %v", path, err)
+	}
+
+	if !s.p.Supports(store.CapRetention) {
+		return settled{db}
+	}
+
+	return db
+}
+
+// settled syncs the store after every write, so the writes of the caches
+// are applied before the checks, which size them to keep every key.
+type settled struct {
+	store.DB
+}
+
+func (db settled) settle(err error) error {
+	if err != nil {
+		return err
+	}
+
+	return db.DB.Sync()
+}
+
+func (db settled) Set(key, value []byte) error {
+	return db.settle(db.DB.Set(key, value))
+}
+
+func (db settled) SetString(key string, value []byte) error {
+	return db.settle(db.DB.SetString(key, value))
+}
+
+func (db settled) SetBulk(kvs ...common.KV) error {
+	return db.settle(db.DB.SetBulk(kvs...))
+}
+
+func (db settled) Del(key []byte) error {
+	return db.settle(db.DB.Del(key))
+}
+
+func (db settled) DelString(key string) error {
+	return db.settle(db.DB.DelString(key))
+}
+
+func (db settled) DelBulk(keys ...[]byte) error {
+	return db.settle(db.DB.DelBulk(keys...))
+}
+
+func (db settled) Reset() error {
+	return db.settle(db.DB.Reset())
+}
+
+// noIter reports whether the store can't iterate, checking that its
+// iterations fail as unsupported then.
+func (s *suite) noIter(t *testing.T, db store.DB) bool {
+	t.Helper()
+
+	if s.p.Supports(store.CapIter) {
+		return false
+	}
+
+	fail := func(key, value []byte) error {
+		return fmt.Errorf("iterated the key %x of a store without iteration", key)
+	}
+
+	if err := db.Iter(fail); !errors.Is(err, store.ErrUnsupported) {
+		t.Fatalf("iter == %v, want %v", err, store.ErrUnsupported)
+	}
+
+	if err := db.IterRange(store.Range{}, fail); !errors.Is(err, store.ErrUnsupported) {
+		t.Fatalf("range iter == %v, want %v", err, store.ErrUnsupported)
+	}
+
+	return true
+}
+
+// load sets the keys [0, count) in a random order.
+func (s *suite) load(t *testing.T, db store.DB) {
+	t.Helper()
+
+	for _, i := range rand.Perm(s.count) {
+		if err := db.Set(Key(i), Value(i, 0)); err != nil {
+			t.Fatalf("failed to set key %d: %v", i, err)
+		}
+	}
+}
+
+// expect checks that the key i has the given value,
+// or doesn't exist if value is nil.
+func expect(t *testing.T, db store.DB, i int, value []byte) {
+	t.Helper()
+
+	v, err := db.Get(Key(i))
+
+	switch {
+	case value == nil && errors.Is(err, store.ErrNotFound):
+		return
+	case value == nil && err == nil:
+		t.Fatalf("key %d == %q, want it missing", i, v)
+	case err != nil:
+		t.Fatalf("failed to get key %d: %v", i, err)
+	}
+
+	if !bytes.Equal(v, value) {
+		t.Fatalf("key %d == %q, want %q", i, v, value)
+	}
+}
+
+func (s *suite) testSetGet(t *testing.T, path string) {
+	db := s.open(t, path)
+
+	s.load(t, db)
+
+	for i := 0; i < s.count; i++ {
+		expect(t, db, i, Value(i, 0))
+	}
+
+	// Overwrite the even keys, with the string variants.
+	for i := 0; i < s.count; i += 2 {
+		if err := db.SetString(string(Key(i)), Value(i, 1)); err != nil {
+			t.Fatalf("failed to overwrite key %d: %v", i, err)
+		}
+	}
+
+	for i := 0; i < s.count; i++ {
+		want := Value(i, 0)
+		if i%2 == 0 {
+			want = Value(i, 1)
+		}
+
+		v, err := db.GetString(string(Key(i)))
+		if err != nil {
+			t.Fatalf("failed to get key %d: %v", i, err)
+		}
+
+		if !bytes.Equal(v, want) {
+			t.Fatalf("key %d == %q, want %q", i, v, want)
+		}
+	}
+}
+
+func (s *suite) testBulk(t *testing.T, path string) {
+	db := s.open(t, path)
+
+	kvs := make([]common.KV, s.count)
+	keys := make([][]byte, s.count+1)
+
+	for i := range kvs {
+		kvs[i] = common.KV{Key: Key(i), Value: Value(i, 0)}
+		keys[i] = Key(i)
+	}
+
+	// Ask for a missing key too.
+	keys[s.count] = Key(s.count)
+
+	if err := db.SetBulk(kvs...); err != nil {
+		t.Fatalf("failed to set bulk: %v", err)
+	}
+
+	res, err := db.GetBulk(keys...)
+	if err != nil {
+		t.Fatalf("failed to get bulk: %v", err)
+	}
+
+	if len(res) != len(keys) {
+		t.Fatalf("got %d entries, want %d", len(res), len(keys))
+	}
+
+	for i, kv := range res {
+		var want []byte
+		if i < s.count {
+			want = Value(i, 0)
+		}
+
+		if !bytes.Equal(kv.Key, keys[i]) {
+			t.Fatalf("entry %d has key %x, want %x", i, kv.Key, keys[i])
+		}
+
+		if !bytes.Equal(kv.Value, want) {
+			t.Fatalf("entry %d == %q, want %q", i, kv.Value, want)
+		}
+
+		if found := i < s.count; kv.Found != found {
+			t.Fatalf("entry %d found == %v, want %v", i, kv.Found, found)
+		}
+	}
+
+	// Delete the even keys, and the missing one.
+	var dels [][]byte
+	for i := 0; i <= s.count; i += 2 {
+		dels = append(dels, Key(i))
+	}
+
+	if err := db.DelBulk(dels...); err != nil {
+		t.Fatalf("failed to delete bulk: %v", err)
+	}
+
+	for i := 0; i < s.count; i++ {
+		var want []byte
+		if i%2 != 0 {
+			want = Value(i, 0)
+		}
+
+		expect(t, db, i, want)
+	}
+}
+
+func (s *suite) testMissing(t *testing.T, path string) {
+	db := s.open(t, path)
+
+	s.load(t, db)
+
+	missing := Key(s.count)
+
+	expect(t, db, s.count, nil)
+
+	if err := db.Del(missing); err != nil {
+		t.Fatalf("failed to delete a missing key: %v", err)
+	}
+
+	if err := db.DelString(string(missing)); err != nil {
+		t.Fatalf("failed to delete a missing key: %v", err)
+	}
+
+	if err := db.DelBulk(missing, Key(s.count+1)); err != nil {
+		t.Fatalf("failed to delete missing keys: %v", err)
+	}
+
+	// The existing keys are untouched.
+	for i := 0; i < s.count; i++ {
+		expect(t, db, i, Value(i, 0))
+	}
+}
+
+func (s *suite) testEmptyValue(t *testing.T, path string) {
+	db := s.open(t, path)
+
+	if err := db.Set(Key(0), []byte{}); err != nil {
+		t.Fatalf("failed to set an empty value: %v", err)
+	}
+
+	v, err := db.Get(Key(0))
+	if err != nil {
+		t.Fatalf("failed to get an empty value: %v", err)
+	}
+
+	if len(v) != 0 {
+		t.Fatalf("key 0 == %q, want an empty value", v)
+	}
+
+	res, err := db.GetBulk(Key(0), Key(1))
+	if err != nil {
+		t.Fatalf("failed to get bulk: %v", err)
+	}
+
+	if !res[0].Found || len(res[0].Value) != 0 {
+		t.Fatalf("entry 0 == %q (found %v), want an empty value", res[0].Value, res[0].Found)
+	}
+
+	if res[1].Found {
+		t.Fatalf("entry 1 found, want it missing")
+	}
+}
+
+func (s *suite) testView(t *testing.T, path string) {
+	db := s.open(t, path)
+
+	s.load(t, db)
+
+	for i := 0; i < s.count; i++ {
+		var v []byte
+
+		err := db.View(Key(i), func(value []byte) error {
+			v = append(v, value...)
+
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("failed to view key %d: %v", i, err)
+		}
+
+		if want := Value(i, 0); !bytes.Equal(v, want) {
+			t.Fatalf("key %d == %q, want %q", i, v, want)
+		}
+	}
+
+	err := db.View(Key(s.count), func(value []byte) error {
+		t.Fatalf("view of a missing key called with %q", value)
+
+		return nil
+	})
+	if !errors.Is(err, store.ErrNotFound) {
+		t.Fatalf("view of a missing key == %v, want %v", err, store.ErrNotFound)
+	}
+
+	err = db.View(Key(0), func(value []byte) error {
+		return errStop
+	})
+	if !errors.Is(err, errStop) {
+		t.Fatalf("view == %v, want the error of the callback", err)
+	}
+}
+
+func (s *suite) testEmptyKey(t *testing.T, path string) {
+	db := s.open(t, path)
+	value := Value(0, 0)
+
+	checks := []struct {
+		op string
+		fn func() error
+	}{
+		{"Set", func() error { return db.Set(nil, value) }},
+		{"SetString", func() error { return db.SetString("", value) }},
+		{"SetBulk", func() error {
+			return db.SetBulk(common.KV{Key: Key(0), Value: value}, common.KV{Value: value})
+		}},
+		{"Get", func() error {
+			_, err := db.Get(nil)
+
+			return err
+		}},
+		{"GetString", func() error {
+			_, err := db.GetString("")
+
+			return err
+		}},
+		{"View", func() error {
+			return db.View(nil, func(value []byte) error {
+				return nil
+			})
+		}},
+		{"GetBulk", func() error {
+			_, err := db.GetBulk(Key(0), nil)
+
+			return err
+		}},
+		{"Del", func() error { return db.Del(nil) }},
+		{"DelString", func() error { return db.DelString("") }},
+		{"DelBulk", func() error { return db.DelBulk(Key(0), nil) }},
+	}
+
+	for _, c := range checks {
+		if err := c.fn(); !errors.Is(err, store.ErrEmptyKey) {
+			t.Errorf("%s with an empty key returned %v, want %v", c.op, err, store.ErrEmptyKey)
+		}
+	}
+}
+
+func (s *suite) testDelete(t *testing.T, path string) {
+	db := s.open(t, path)
+
+	s.load(t, db)
+
+	// Overwrite the keys before deleting them, so every version is gone.
+	for i := 0; i < s.count; i++ {
+		if err := db.Set(Key(i), Value(i, 1)); err != nil {
+			t.Fatalf("failed to overwrite key %d: %v", i, err)
+		}
+	}
+
+	for i := 0; i < s.count; i += 2 {
+		if err := db.Del(Key(i)); err != nil {
+			t.Fatalf("failed to delete key %d: %v", i, err)
+		}
+	}
+
+	for i := 0; i < s.count; i++ {
+		var want []byte
+		if i%2 != 0 {
+			want = Value(i, 1)
+		}
+
+		expect(t, db, i, want)
+	}
+
+	if s.noIter(t, db) {
+		return
+	}
+
+	n := 0
+
+	err := db.Iter(func(key, value []byte) error {
+		if i := int(binary.BigEndian.Uint64(key)); i%2 == 0 {
+			return fmt.Errorf("iterated the deleted key %d", i)
+		}
+
+		n++
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := s.count / 2; n != want {
+		t.Fatalf("iterated %d keys, want %d", n, want)
+	}
+}
+
+func (s *suite) testIter(t *testing.T, path string) {
+	db := s.open(t, path)
+	if s.noIter(t, db) {
+		t.Skip("iteration unsupported")
+	}
+
+	if err := db.Iter(func(key, value []byte) error {
+		return fmt.Errorf("iterated the key %x of an empty store", key)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	s.load(t, db)
+
+	seen := make([]bool, s.count)
+
+	err := db.Iter(func(key, value []byte) error {
+		if len(key) != 8 {
+			return fmt.Errorf("unexpected key %x", key)
+		}
+
+		i := int(binary.BigEndian.Uint64(key))
+
+		switch {
+		case i >= s.count:
+			return fmt.Errorf("unexpected key %d", i)
+		case seen[i]:
+			return fmt.Errorf("key %d iterated twice", i)
+		case !bytes.Equal(value, Value(i, 0)):
+			return fmt.Errorf("key %d == %q, want %q", i, value, Value(i, 0))
+		}
+
+		seen[i] = true
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range seen {
+		if !seen[i] {
+			t.Fatalf("key %d not iterated", i)
+		}
+	}
+
+	// The error of the function stops the iteration.
+	n := 0
+
+	err = db.Iter(func(key, value []byte) error {
+		n++
+
+		return errStop
+	})
+	if !errors.Is(err, errStop) || n != 1 {
+		t.Fatalf("stopped iteration returned %v after %d keys", err, n)
+	}
+}
+
+func (s *suite) testIterRange(t *testing.T, path string) {
+	db := s.open(t, path)
+	if s.noIter(t, db) {
+		t.Skip("iteration unsupported")
+	}
+
+	s.load(t, db)
+
+	start, end := s.count/4, s.count/2
+	prefix := start &^ 0xff
+
+	ranges := []struct {
+		r        store.Range
+		from, to int // Expected keys, in [from, to).
+	}{
+		{store.Range{}, 0, s.count},
+		{store.Range{Start: Key(start)}, start, s.count},
+		{store.Range{End: Key(end)}, 0, end},
+		{store.Range{Start: Key(start), End: Key(end)}, start, end},
+		{store.Range{Start: Key(start), End: Key(end), Limit: 10}, start, end},
+		{store.Range{Start: Key(s.count), End: Key(s.count + 10)}, s.count, s.count},
+		{store.PrefixRange(Key(prefix)[:7]), prefix, prefix + 0x100},
+	}
+
+	for _, tc := range ranges {
+		for _, reverse := range []bool{false, true} {
+			r := tc.r
+			r.Reverse = reverse
+
+			var want []int
+
+			for i := tc.from; i < tc.to && i < s.count; i++ {
+				want = append(want, i)
+			}
+
+			if reverse {
+				for i, j := 0, len(want)-1; i < j; i, j = i+1, j-1 {
+					want[i], want[j] = want[j], want[i]
+				}
+			}
+
+			if r.Limit > 0 && len(want) > r.Limit {
+				want = want[:r.Limit]
+			}
+
+			var got []int
+
+			err := db.IterRange(r, func(key, value []byte) error {
+				i := int(binary.BigEndian.Uint64(key))
+
+				if !bytes.Equal(value, Value(i, 0)) {
+					return fmt.Errorf("key %d == %q, want %q", i, value, Value(i, 0))
+				}
+
+				got = append(got, i)
+
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("range %+v: %v", r, err)
+			}
+
+			if fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Fatalf("range %+v iterated %v, want %v", r, got, want)
+			}
+		}
+	}
+
+	// The error of the function stops the iteration.
+	n := 0
+
+	err := db.IterRange(store.Range{}, func(key, value []byte) error {
+		n++
+
+		return errStop
+	})
+	if !errors.Is(err, errStop) || n != 1 {
+		t.Fatalf("stopped iteration returned %v after %d keys", err, n)
+	}
+}
+
+func (s *suite) testSyncFlush(t *testing.T, path string) {
+	db := s.open(t, path)
+
+	s.load(t, db)
+
+	if err := db.Sync(); err != nil {
+		t.Fatalf("failed to sync: %v", err)
+	}
+
+	if err := db.Flush(); err != nil {
+		t.Fatalf("failed to flush: %v", err)
+	}
+
+	// Neither of them drops any data, and the store is still usable.
+	for i := 0; i < s.count; i++ {
+		expect(t, db, i, Value(i, 0))
+	}
+
+	if err := db.Set(Key(s.count), Value(s.count, 0)); err != nil {
+		t.Fatalf("failed to set after flushing: %v", err)
+	}
+
+	expect(t, db, s.count, Value(s.count, 0))
+}
+
+func (s *suite) testReset(t *testing.T, path string) {
+	db := s.open(t, path)
+
+	s.load(t, db)
+
+	if err := db.Reset(); err != nil {
+		t.Fatalf("failed to reset: %v", err)
+	}
+
+	for i := 0; i < s.count; i++ {
+		expect(t, db, i, nil)
+	}
+
+	if !s.noIter(t, db) {
+		if err := db.Iter(func(key, value []byte) error {
+			return fmt.Errorf("iterated the key %x after resetting", key)
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The store is still usable.
+	s.load(t, db)
+
+	for i := 0; i < s.count; i++ {
+		expect(t, db, i, Value(i, 0))
+	}
+}
+
+func (s *suite) testClose(t *testing.T, path string) {
+	db := s.openUnmanaged(t, path)
+
+	s.load(t, db)
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("failed to close: %v", err)
+	}
+
+	if s.p.Volatile || s.memory {
+		t.Skip("the data of a volatile store is lost when it's closed")
+	}
+
+	db = s.open(t, path)
+
+	for i := 0; i < s.count; i++ {
+		expect(t, db, i, Value(i, 0))
+	}
+}
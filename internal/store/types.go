@@ -6,14 +6,31 @@ type DB interface {
 	Set(key, value []byte) error
 	SetString(key string, value []byte) error
 	SetBulk(kvs ...common.KV) error
+	// Get returns ErrNotFound if the key doesn't exist, so an empty value
+	// is told apart from a missing key.
 	Get(key []byte) ([]byte, error)
 	GetString(key string) ([]byte, error)
+	// GetBulk returns an entry per key, whose Found field reports whether
+	// the key exists, instead of failing with ErrNotFound.
 	GetBulk(keys ...[]byte) ([]common.KV, error)
+	// View calls fn with the value of the key, which is only valid until
+	// fn returns, so the engines that support it don't copy it. It returns
+	// ErrNotFound if the key doesn't exist.
+	View(key []byte, fn common.ViewFunc) error
 	Del(key []byte) error
 	DelString(key string) error
 	DelBulk(key ...[]byte) error
 	Iter(fn common.IterFunc) error
+	// IterRange calls fn with the keys of the range r in order.
+	// The key and value are only valid until fn returns.
+	IterRange(r Range, fn common.IterFunc) error
+	// Sync is a durability barrier: the writes completed before it are
+	// persisted when it returns.
+	Sync() error
+	// Flush writes the in-memory data of the engine (e.g. the memtable)
+	// to disk.
 	Flush() error
-	// Reset() error
+	// Reset drops all the data.
+	Reset() error
 	Close() error
 }
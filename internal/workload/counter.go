@@ -0,0 +1,65 @@
+package workload
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// ackCounter counts the records of a store as YCSB's acknowledged counter
+// does: the inserts take the index of their record with next, and the
+// count only advances past it once the insert is acknowledged, so the
+// reads never choose a record whose write isn't done.
+type ackCounter struct {
+	// Index of the next insert.
+	next uint64
+
+	// Records up to the first one whose insert isn't acknowledged.
+	limit uint64
+
+	mu sync.Mutex
+
+	// Acknowledged inserts past limit.
+	acked map[uint64]struct{}
+}
+
+func newAckCounter(records uint64) *ackCounter {
+	return &ackCounter{
+		next:  records,
+		limit: records,
+		acked: make(map[uint64]struct{}),
+	}
+}
+
+// Next returns the index of the record of a new insert.
+func (c *ackCounter) Next() uint64 {
+	return atomic.AddUint64(&c.next, 1) - 1
+}
+
+// Ack acknowledges the insert of the record i.
+func (c *ackCounter) Ack(i uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	limit := atomic.LoadUint64(&c.limit)
+	if i != limit {
+		c.acked[i] = struct{}{}
+
+		return
+	}
+
+	for limit++; ; limit++ {
+		if _, ok := c.acked[limit]; !ok {
+			break
+		}
+
+		delete(c.acked, limit)
+	}
+
+	atomic.StoreUint64(&c.limit, limit)
+}
+
+// Records returns the number of records whose inserts are acknowledged,
+// up to the first one that isn't.
+func (c *ackCounter) Records() uint64 {
+	return atomic.LoadUint64(&c.limit)
+}
@@ -0,0 +1,125 @@
+package workload
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+
+	"github.com/savsgio/kvbench/internal/generator"
+	"github.com/savsgio/kvbench/internal/store"
+)
+
+// KeyFunc returns the key of the i-th record.
+type KeyFunc func(i uint64) []byte
+
+// ValueFunc returns the value to write.
+type ValueFunc func(r *rand.Rand) []byte
+
+// Executor runs the operations of a workload against a store.
+type Executor struct {
+	workload Workload
+	db       store.ContextDB
+	key      KeyFunc
+	value    ValueFunc
+	chooser  chooser
+	keys     generator.Chooser
+
+	// Records of the store, increased by the inserts once they succeed.
+	records *ackCounter
+}
+
+// Worker executes operations of a workload. It's not safe for concurrent
+// use, so each goroutine must use its own worker.
+type Worker struct {
+	e *Executor
+	r *rand.Rand
+}
+
+// NewExecutor returns an executor of the workload w on a store loaded with
+// the given number of records, which chooses the keys of the operations
+// with keys and writes the values returned by value in every insert and
+// update.
+func NewExecutor(
+	w Workload, db store.ContextDB, records uint64, key KeyFunc, value ValueFunc, keys generator.Chooser,
+) *Executor {
+	return &Executor{
+		workload: w,
+		db:       db,
+		key:      key,
+		value:    value,
+		chooser:  newChooser(w),
+		keys:     keys,
+		records:  newAckCounter(records),
+	}
+}
+
+// Workload returns the workload of the executor.
+func (e *Executor) Workload() Workload {
+	return e.workload
+}
+
+// NewWorker returns a worker whose random choices are seeded by seed.
+func (e *Executor) NewWorker(seed int64) *Worker {
+	return &Worker{
+		e: e,
+		r: rand.New(rand.NewSource(seed)),
+	}
+}
+
+// nextKey returns the key of an existing record.
+func (w *Worker) nextKey() []byte {
+	return w.e.key(w.e.keys.Next(w.r, w.e.records.Records()))
+}
+
+// Next executes the next random operation of the workload.
+func (w *Worker) Next(ctx context.Context) (Op, error) {
+	op := w.e.chooser.next(w.r)
+
+	return op, w.Do(ctx, op)
+}
+
+// Do executes the operation op, until ctx is done.
+func (w *Worker) Do(ctx context.Context, op Op) error {
+	e := w.e
+
+	switch op {
+	case OpRead:
+		_, err := e.db.GetContext(ctx, w.nextKey())
+
+		return err
+	case OpUpdate:
+		return e.db.SetContext(ctx, w.nextKey(), e.value(w.r))
+	case OpInsert:
+		i := e.records.Next()
+
+		// A failed insert is never acknowledged, since its record may be
+		// missing, so the records chosen stop growing at it.
+		if err := e.db.SetContext(ctx, e.key(i), e.value(w.r)); err != nil {
+			return err
+		}
+
+		e.records.Ack(i)
+
+		return nil
+	case OpScan:
+		return w.scan(ctx, 1+w.r.Intn(e.workload.MaxScanLength))
+	case OpReadModifyWrite:
+		key := w.nextKey()
+
+		// A missing key is written all the same.
+		if _, err := e.db.GetContext(ctx, key); err != nil && !errors.Is(err, store.ErrNotFound) {
+			return err
+		}
+
+		return e.db.SetContext(ctx, key, e.value(w.r))
+	default:
+		return store.ErrUnsupported
+	}
+}
+
+// scan reads up to n records from an existing key.
+func (w *Worker) scan(ctx context.Context, n int) error {
+	return w.e.db.IterRangeContext(ctx, store.Range{Start: w.nextKey(), Limit: n}, func(key, value []byte) error {
+		return nil
+	})
+}
@@ -0,0 +1,50 @@
+package workload
+
+import (
+	"math/rand"
+	"sync"
+
+	"github.com/savsgio/kvbench/internal/common"
+	"github.com/savsgio/kvbench/internal/store"
+)
+
+const loadBatchSize = 1000
+
+// Load inserts the records [0, n) in batches, using the given number of
+// concurrent workers.
+func Load(db store.DB, n uint64, key KeyFunc, value ValueFunc, workers int) error {
+	var wg sync.WaitGroup
+	var once sync.Once
+	var loadErr error
+
+	for j := 0; j < workers; j++ {
+		wg.Add(1)
+
+		go func(id uint64) {
+			defer wg.Done()
+
+			r := rand.New(rand.NewSource(int64(id)))
+			kvs := make([]common.KV, 0, loadBatchSize)
+
+			for i := id; i < n; i += uint64(workers) {
+				kvs = append(kvs, common.KV{Key: key(i), Value: value(r)})
+
+				if len(kvs) < loadBatchSize && i+uint64(workers) < n {
+					continue
+				}
+
+				if err := db.SetBulk(kvs...); err != nil {
+					once.Do(func() { loadErr = err })
+
+					return
+				}
+
+				kvs = kvs[:0]
+			}
+		}(uint64(j))
+	}
+
+	wg.Wait()
+
+	return loadErr
+}
@@ -0,0 +1,242 @@
+// Package workload implements YCSB-style workloads on top of store.DB.
+//
+// A workload is a mix of operations (read, update, insert, scan and
+// read-modify-write) chosen randomly by their proportions.
+package workload
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/savsgio/kvbench/internal/generator"
+)
+
+// Op is an operation of a workload.
+type Op int
+
+// Operations of the workloads.
+const (
+	OpRead Op = iota
+	OpUpdate
+	OpInsert
+	OpScan
+	OpReadModifyWrite
+
+	numOps
+)
+
+var opNames = [numOps]string{
+	OpRead:            "read",
+	OpUpdate:          "update",
+	OpInsert:          "insert",
+	OpScan:            "scan",
+	OpReadModifyWrite: "rmw",
+}
+
+func (op Op) String() string {
+	if op < 0 || op >= numOps {
+		return "op(" + strconv.Itoa(int(op)) + ")"
+	}
+
+	return opNames[op]
+}
+
+// Ops returns all the operations ordered by their value.
+func Ops() []Op {
+	ops := make([]Op, numOps)
+	for i := range ops {
+		ops[i] = Op(i)
+	}
+
+	return ops
+}
+
+// DefaultMaxScanLength is the YCSB default of the maximum scan length.
+const DefaultMaxScanLength = 100
+
+// Workload defines the mix of operations.
+type Workload struct {
+	Name string
+
+	// Proportions of each operation, which must add up to 1.
+	Read            float64
+	Update          float64
+	Insert          float64
+	Scan            float64
+	ReadModifyWrite float64
+
+	// Distribution of the keys, as defined in the generator package.
+	Distribution string
+
+	// MaxScanLength is the maximum number of records of a scan,
+	// the length of each scan is uniformly chosen in [1, MaxScanLength].
+	MaxScanLength int
+}
+
+// The standard YCSB core workloads.
+var presets = map[string]Workload{
+	"a": {Name: "ycsb-a", Read: 0.5, Update: 0.5, Distribution: generator.Zipfian},
+	"b": {Name: "ycsb-b", Read: 0.95, Update: 0.05, Distribution: generator.Zipfian},
+	"c": {Name: "ycsb-c", Read: 1, Distribution: generator.Zipfian},
+	"d": {Name: "ycsb-d", Read: 0.95, Insert: 0.05, Distribution: generator.Latest},
+	"e": {Name: "ycsb-e", Scan: 0.95, Insert: 0.05, Distribution: generator.Zipfian},
+	"f": {Name: "ycsb-f", Read: 0.5, ReadModifyWrite: 0.5, Distribution: generator.Zipfian},
+}
+
+// Presets returns the names of the preset workloads.
+func Presets() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Parse returns the workload defined by spec, which is either the name of
+// a preset (a-f) or a comma separated mix of proportions,
+// e.g. "read=0.9,update=0.1,scanlength=50". The scans read up to
+// scanLength records, unless the mix sets its own scanlength.
+func Parse(spec string, scanLength int) (Workload, error) {
+	if w, ok := presets[strings.ToLower(spec)]; ok {
+		w.MaxScanLength = scanLength
+
+		if err := w.Validate(); err != nil {
+			return Workload{}, err
+		}
+
+		return w, nil
+	}
+
+	if !strings.Contains(spec, "=") {
+		return Workload{}, fmt.Errorf("unknown workload: %v", spec)
+	}
+
+	w := Workload{
+		Name:          "workload",
+		Distribution:  generator.Uniform,
+		MaxScanLength: scanLength,
+	}
+
+	for _, field := range strings.Split(spec, ",") {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return Workload{}, fmt.Errorf("invalid workload field: %v", field)
+		}
+
+		key := strings.TrimSpace(kv[0])
+		value := strings.TrimSpace(kv[1])
+
+		if key == "scanlength" {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return Workload{}, fmt.Errorf("invalid workload scan length: %w", err)
+			}
+
+			w.MaxScanLength = n
+
+			continue
+		}
+
+		p, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return Workload{}, fmt.Errorf("invalid workload proportion of %s: %w", key, err)
+		}
+
+		switch key {
+		case "read":
+			w.Read = p
+		case "update":
+			w.Update = p
+		case "insert":
+			w.Insert = p
+		case "scan":
+			w.Scan = p
+		case "rmw":
+			w.ReadModifyWrite = p
+		default:
+			return Workload{}, fmt.Errorf("unknown workload operation: %v", key)
+		}
+	}
+
+	if err := w.Validate(); err != nil {
+		return Workload{}, err
+	}
+
+	return w, nil
+}
+
+func (w Workload) proportions() [numOps]float64 {
+	return [numOps]float64{
+		OpRead:            w.Read,
+		OpUpdate:          w.Update,
+		OpInsert:          w.Insert,
+		OpScan:            w.Scan,
+		OpReadModifyWrite: w.ReadModifyWrite,
+	}
+}
+
+// Validate checks the proportions of the workload.
+func (w Workload) Validate() error {
+	var sum float64
+
+	for i, p := range w.proportions() {
+		if p < 0 {
+			return fmt.Errorf("negative workload proportion of %s: %v", Op(i), p)
+		}
+
+		sum += p
+	}
+
+	if math.Abs(sum-1) > 1e-9 {
+		return fmt.Errorf("workload proportions must add up to 1, got %v", sum)
+	}
+
+	if w.Scan > 0 && w.MaxScanLength < 1 {
+		return fmt.Errorf("invalid workload scan length: %d", w.MaxScanLength)
+	}
+
+	return nil
+}
+
+// chooser picks operations by their proportions.
+type chooser struct {
+	cumulative [numOps]float64
+}
+
+func newChooser(w Workload) chooser {
+	var ch chooser
+	var sum float64
+
+	for i, p := range w.proportions() {
+		sum += p
+		ch.cumulative[i] = sum
+	}
+
+	return ch
+}
+
+func (ch chooser) next(r *rand.Rand) Op {
+	f := r.Float64() * ch.cumulative[numOps-1]
+
+	for i, c := range ch.cumulative {
+		if f < c {
+			return Op(i)
+		}
+	}
+
+	// Only reached by rounding errors, pick the last one with proportion.
+	for i := numOps - 1; i > 0; i-- {
+		if ch.cumulative[i] > ch.cumulative[i-1] {
+			return i
+		}
+	}
+
+	return OpRead
+}
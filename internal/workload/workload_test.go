@@ -0,0 +1,87 @@
+package workload
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	for _, name := range Presets() {
+		w, err := Parse(name, DefaultMaxScanLength)
+		if err != nil {
+			t.Fatalf("preset %s: %v", name, err)
+		}
+
+		if err := w.Validate(); err != nil {
+			t.Errorf("preset %s: %v", name, err)
+		}
+	}
+
+	w, err := Parse("read=0.7,update=0.2,rmw=0.1,scanlength=10", DefaultMaxScanLength)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if w.Read != 0.7 || w.Update != 0.2 || w.ReadModifyWrite != 0.1 || w.MaxScanLength != 10 {
+		t.Errorf("unexpected workload: %+v", w)
+	}
+
+	// The scan length applies to the presets and the mixes without their own.
+	for _, spec := range []string{"e", "scan=1"} {
+		if w, err := Parse(spec, 50); err != nil || w.MaxScanLength != 50 {
+			t.Errorf("workload %s scans up to %d records (%v), want 50", spec, w.MaxScanLength, err)
+		}
+	}
+
+	for _, spec := range []string{"z", "read=0.5", "read=1,foo=0", "read=x", "read=1.5,update=-0.5"} {
+		if _, err := Parse(spec, DefaultMaxScanLength); err == nil {
+			t.Errorf("expected error parsing %q", spec)
+		}
+	}
+}
+
+func TestChooser(t *testing.T) {
+	w, _ := Parse("b", DefaultMaxScanLength)
+	ch := newChooser(w)
+	r := rand.New(rand.NewSource(1))
+
+	const n = 100000
+
+	var counts [numOps]int
+
+	for i := 0; i < n; i++ {
+		counts[ch.next(r)]++
+	}
+
+	if counts[OpRead]+counts[OpUpdate] != n {
+		t.Fatalf("unexpected operations: %v", counts)
+	}
+
+	if p := float64(counts[OpUpdate]) / n; p < 0.04 || p > 0.06 {
+		t.Errorf("update proportion == %v, want ~0.05", p)
+	}
+}
+
+func TestAckCounter(t *testing.T) {
+	c := newAckCounter(10)
+
+	a, b, d := c.Next(), c.Next(), c.Next()
+	if a != 10 || b != 11 || d != 12 {
+		t.Fatalf("inserts of the records %d, %d and %d, want 10, 11 and 12", a, b, d)
+	}
+
+	// The records past an unacknowledged insert aren't counted.
+	for _, tt := range []struct {
+		ack, want uint64
+	}{
+		{11, 10},
+		{12, 10},
+		{10, 13},
+	} {
+		c.Ack(tt.ack)
+
+		if n := c.Records(); n != tt.want {
+			t.Errorf("%d records after the ack of %d, want %d", n, tt.ack, tt.want)
+		}
+	}
+}
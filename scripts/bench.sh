@@ -2,43 +2,13 @@
 
 SIZE=256
 
-STORES=("badger" "buntdb" "leveldb" "nutsdb" "pebble" "pogreb")
-
 export LD_LIBRARY_PATH=/usr/local/lib
 
-`rm  -fr .*db`
-`rm  -fr *.db`
-`rm  -fr pogreb.*`
 `rm -f benchmarks/test.log`
+`rm -f benchmarks/results*.csv`
 
-echo "=========== test nofsync ==========="
-for i in "${STORES[@]}"
-do
-	dt=`date`
-
-	echo "[$dt] Store: $i"
-	./bin/kvbench -d 1m -size ${SIZE} -s "$i" >> benchmarks/test_$i.log 2>&1
-
-	sleep 1m
-done
-
-`rm  -fr .*db`
-`rm  -fr *.db`
-`rm  -fr pogreb.*`
-
-echo ""
-echo "=========== test fsync ==========="
-
-for i in "${STORES[@]}"
-do
-	dt=`date`
-
-	echo "[$dt] Store: $i"
-	./bin/kvbench -d 1m -size ${SIZE} -s "$i" -fsync >> benchmarks/test_$i.log 2>&1
-
-	sleep 1m
-done
+dt=`date`
 
-`rm  -fr .*db`
-`rm  -fr *.db`
-`rm  -fr pogreb.*`
+echo "[$dt] Stores: $(./bin/kvbench -s list | xargs)"
+./bin/kvbench -d 1m -size ${SIZE} -s all -fsync=false,true -cooldown 1m \
+	-format csv -o benchmarks/results.csv >> benchmarks/test.log 2>&1
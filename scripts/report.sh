@@ -2,30 +2,79 @@
 
 if [ $# != 0 ]
 then
-    logfile="$1"-test
+    results="$1"-results
 else
-    logfile=test
+    results=results
 fi
 
-`rm -f benchmarks/*.csv`
+`rm -f benchmarks/*_throughputs.csv benchmarks/*_time.csv`
 
-STORES=("badger" "buntdb" "leveldb" "nutsdb" "pebble" "pogreb")
+PHASES="set,get,setmixed,getmixed,del"
 
-echo "name,type,set,get,set-mixed,get-mixed,del" >> benchmarks/nofsync_throughputs.csv
-echo "name,type,set,get,set-mixed,get-mixed,del" >> benchmarks/nofsync_time.csv
-echo "name,type,set,get,set-mixed,get-mixed,del" >> benchmarks/fsync_throughputs.csv
-echo "name,type,set,get,set-mixed,get-mixed,del" >> benchmarks/fsync_time.csv
+# table <mode> <column> prints a row per engine with the given column of
+# each phase, reading the csv results written by kvbench, whose quoted
+# fields (e.g. value_dist, error_types) may hold commas.
+table() {
+    echo "name,set,get,set-mixed,get-mixed,del"
 
-for i in "${STORES[@]}"
-do
+    awk -v mode="$1" -v field="$2" -v phases="${PHASES}" '
+    # csv splits the csv line s into f, unquoting its quoted fields,
+    # and returns the number of fields.
+    function csv(s, f,    n, i, c, v, quoted) {
+        split("", f)
+        n = 0
+        v = ""
+        quoted = 0
+        for (i = 1; i <= length(s); i++) {
+            c = substr(s, i, 1)
+            if (quoted) {
+                if (c != "\"") {
+                    v = v c
+                } else if (substr(s, i + 1, 1) == "\"") {
+                    v = v c
+                    i++
+                } else {
+                    quoted = 0
+                }
+            } else if (c == "\"") {
+                quoted = 1
+            } else if (c == ",") {
+                f[++n] = v
+                v = ""
+            } else {
+                v = v c
+            }
+        }
+        f[++n] = v
+        return n
+    }
+    FNR == 1 {
+        n = csv($0, f)
+        for (i = 1; i <= n; i++) col[f[i]] = i
+        next
+    }
+    {
+        csv($0, f)
+    }
+    f[col["mode"]] == mode {
+        engines[f[col["engine"]]] = 1
+        values[f[col["engine"]], f[col["phase"]]] = f[col[field]]
+    }
+    END {
+        n = split(phases, ps, ",")
+        for (e in engines) {
+            row = e "/" mode
+            for (i = 1; i <= n; i++) {
+                v = values[e, ps[i]]
+                if (v != "") v = sprintf("%d", v)
+                row = row "," v
+            }
+            print row
+        }
+    }' benchmarks/${results}*.csv | sort
+}
 
-    data=`grep -e ^${i}/nofsync  benchmarks/${logfile}_${i}.log|awk '{print $4}'|xargs| tr ' ' ','`
-    echo "${i}/nofsync,${data}" >> benchmarks/nofsync_throughputs.csv
-    data=`grep -e ^${i}/nofsync  benchmarks/${logfile}_${i}.log|awk '{print $7}'|xargs| tr ' ' ','`
-    echo "${i}/nofsync,${data}" >> benchmarks/nofsync_time.csv
-
-    data=`grep -e ^${i}/fsync  benchmarks/${logfile}_${i}.log|awk '{print $4}'|xargs| tr ' ' ','`
-    echo "${i}/fsync,${data}" >> benchmarks/fsync_throughputs.csv
-    data=`grep -e ^${i}/nofsync  benchmarks/${logfile}_${i}.log|awk '{print $7}'|xargs| tr ' ' ','`
-    echo "${i}/fsync,${data}" >> benchmarks/fsync_time.csv
-done
+table nofsync throughput >> benchmarks/nofsync_throughputs.csv
+table nofsync mean_ns >> benchmarks/nofsync_time.csv
+table fsync throughput >> benchmarks/fsync_throughputs.csv
+table fsync mean_ns >> benchmarks/fsync_time.csv